@@ -24,13 +24,17 @@ func main() {
 		fmt.Printf("Error opening input file %s: %s\n", fname, err.Error())
 		os.Exit(2)
 	}
-	if parsedFile, err := gerber_rs274x.ParseGerberFile(inputFile); err != nil {
+	if parsedFile, parseErrors, err := gerber_rs274x.ParseGerberFile(inputFile); err != nil {
 		inputFile.Close()
 		fmt.Printf("Error parsing gerber file: %v\n", err)
 		os.Exit(3)
 	} else {
 		inputFile.Close()
 
+		for _, parseError := range parseErrors {
+			fmt.Println(parseError)
+		}
+
 		if parsedFile == nil {
 			return //
 		}
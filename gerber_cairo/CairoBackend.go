@@ -0,0 +1,144 @@
+package gerber_cairo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/clucia/go-gerber-rs274x/gerber_rs274x"
+	cairo "github.com/ungerik/go-cairo"
+)
+
+// CairoBackend renders an interpreted gerber image onto a cairo surface.  The image is scaled to fit the surface, with a
+// 5% margin on each side, and drawn in black on a transparent background (or the other way round for negative images)
+type CairoBackend struct {
+	width     int
+	height    int
+	surface   *cairo.Surface
+	tolerance float64
+}
+
+func NewCairoBackend(width int, height int) *CairoBackend {
+	return &CairoBackend{
+		width:  width,
+		height: height,
+	}
+}
+
+// GetSurface returns the surface the image has been drawn on.  It is only available once BeginImage has been called
+func (backend *CairoBackend) GetSurface() *cairo.Surface {
+	return backend.surface
+}
+
+func (backend *CairoBackend) BeginImage(image *gerber_rs274x.GerberImage) error {
+	xMin, xMax, yMin, yMax := image.GetBounds()
+
+	// Build 5% margin on each side into the scaling
+	xMargin := float64(backend.width) * 0.1
+	yMargin := float64(backend.height) * 0.1
+
+	// Compute the appropriate scaling factor.  An empty image has no size to fit, so it's just drawn at one pixel per
+	// millimetre
+	xScale := (float64(backend.width) - xMargin) / (xMax - xMin)
+	yScale := (float64(backend.height) - yMargin) / (yMax - yMin)
+	scaleFactor := math.Min(xScale, yScale)
+	if math.IsInf(scaleFactor, 0) || math.IsNaN(scaleFactor) {
+		scaleFactor = 1.0
+	}
+
+	// Compute offsets to apply to all coordinates to start them at zero and account for margins
+	xOffset := -(xMin * scaleFactor) + (xMargin / 2.0)
+	yOffset := -(yMin * scaleFactor) + (yMargin / 2.0)
+
+	// Construct the surface we're drawing to
+	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, backend.width, backend.height)
+	surface.SetAntialias(cairo.ANTIALIAS_NONE)
+
+	// Objects are drawn as their outlines followed by their holes, which only works with the even/odd fill rule
+	surface.SetFillRule(cairo.FILL_RULE_EVEN_ODD)
+	// Invert the Y-axis.  This is to correct for the difference in coordinate frames between the gerber file and cairo
+	surface.Scale(1.0, -1.0)
+	surface.Translate(0.0, float64(-backend.height))
+	surface.Translate(xOffset, yOffset)
+	surface.Scale(scaleFactor, scaleFactor)
+
+	// A negative image starts out completely dark.  The polarity of the objects already has the image polarity applied
+	if image.GetImagePolarity() == gerber_rs274x.CLEAR_POLARITY {
+		surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
+		surface.Paint()
+	}
+
+	// Curves only need to be accurate to a fraction of a pixel
+	backend.tolerance = 0.25 / scaleFactor
+	backend.surface = surface
+
+	return nil
+}
+
+func (backend *CairoBackend) DrawObject(graphicsObject *gerber_rs274x.GraphicsObject) error {
+	layers, err := graphicsObject.GetPolygons(backend.tolerance)
+	if err != nil {
+		return err
+	}
+
+	// Macro apertures can erase parts of themselves, so the layers are flattened into the final shape of the object,
+	// which is then drawn with the object's own polarity
+	surface := backend.surface
+	for _, shape := range gerber_rs274x.FlattenLayers(layers) {
+		for _, contour := range shape.GetContours() {
+			if len(contour) == 0 {
+				continue
+			}
+
+			surface.MoveTo(contour[0].X, contour[0].Y)
+			for _, point := range contour[1:] {
+				surface.LineTo(point.X, point.Y)
+			}
+			surface.ClosePath()
+		}
+	}
+
+	switch graphicsObject.GetPolarity() {
+	case gerber_rs274x.DARK_POLARITY:
+		surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
+
+	case gerber_rs274x.CLEAR_POLARITY:
+		surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
+	}
+	surface.Fill()
+
+	return nil
+}
+
+func (backend *CairoBackend) EndImage() error {
+	return nil
+}
+
+// GenerateSurface renders a parsed file to a PNG image
+func GenerateSurface(outFileName string, parsedFile []gerber_rs274x.DataBlock) error {
+	image, err := gerber_rs274x.InterpretGerberFile(parsedFile)
+	if err != nil {
+		return err
+	}
+
+	xMin, xMax, yMin, yMax := image.GetBounds()
+	fmt.Printf("X Bounds: (%f %f) Y Bounds: (%f %f)\n", xMin, xMax, yMin, yMax)
+
+	backend := NewCairoBackend(800, 800)
+	err = image.Render(backend)
+	if surface := backend.GetSurface(); surface != nil {
+		if err == nil {
+			surface.WriteToPNG(outFileName)
+		}
+		surface.Finish()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Make sure that the entire file was rendered
+	if !image.IsComplete() {
+		return fmt.Errorf("Render of file completed without reaching end of file code (M02)")
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package gerber_rs274x
+
+import "math"
+
+const MM_PER_INCH float64 = 25.4
+
+// AffineTransform maps points as x' = (xx * x) + (xy * y) + x0, y' = (yx * x) + (yy * y) + y0
+type AffineTransform struct {
+	xx float64
+	xy float64
+	yx float64
+	yy float64
+	x0 float64
+	y0 float64
+}
+
+func newScaleTransform(scale float64) AffineTransform {
+	return AffineTransform{xx: scale, yy: scale}
+}
+
+// newRotationTransform rotates counterclockwise about the origin by the given angle, in radians
+func newRotationTransform(angle float64) AffineTransform {
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	return AffineTransform{xx: cos, xy: -sin, yx: sin, yy: cos}
+}
+
+func (transform AffineTransform) Apply(point Point) Point {
+	return Point{
+		(transform.xx * point.X) + (transform.xy * point.Y) + transform.x0,
+		(transform.yx * point.X) + (transform.yy * point.Y) + transform.y0,
+	}
+}
+
+// ApplyLinear applies the transform without its translation, for mapping sizes and directions rather than positions
+func (transform AffineTransform) ApplyLinear(point Point) Point {
+	return Point{
+		(transform.xx * point.X) + (transform.xy * point.Y),
+		(transform.yx * point.X) + (transform.yy * point.Y),
+	}
+}
+
+// Then returns the transform that applies this transform first, and then the supplied one
+func (transform AffineTransform) Then(next AffineTransform) AffineTransform {
+	return AffineTransform{
+		xx: (next.xx * transform.xx) + (next.xy * transform.yx),
+		xy: (next.xx * transform.xy) + (next.xy * transform.yy),
+		yx: (next.yx * transform.xx) + (next.yy * transform.yx),
+		yy: (next.yx * transform.xy) + (next.yy * transform.yy),
+		x0: (next.xx * transform.x0) + (next.xy * transform.y0) + next.x0,
+		y0: (next.yx * transform.x0) + (next.yy * transform.y0) + next.y0,
+	}
+}
+
+// Linear returns the transform without its translation
+func (transform AffineTransform) Linear() AffineTransform {
+	transform.x0, transform.y0 = 0.0, 0.0
+	return transform
+}
+
+// IsMirrored reports whether the transform flips the orientation of shapes (and so turns clockwise arcs counterclockwise)
+func (transform AffineTransform) IsMirrored() bool {
+	return ((transform.xx * transform.yy) - (transform.xy * transform.yx)) < 0.0
+}
+
+// isAxisAligned reports whether the transform keeps horizontal and vertical lines horizontal and vertical, which it does
+// when it only rotates by multiples of 90 degrees
+func (transform AffineTransform) isAxisAligned() bool {
+	unrotated := math.Abs(transform.xy) < 1e-9 && math.Abs(transform.yx) < 1e-9
+	quarterTurn := math.Abs(transform.xx) < 1e-9 && math.Abs(transform.yy) < 1e-9
+
+	return unrotated || quarterTurn
+}
+
+// Scale returns the factor the transform scales lengths by.  Non-uniform scaling is averaged, which is only approximate
+func (transform AffineTransform) Scale() float64 {
+	return math.Sqrt(math.Abs((transform.xx * transform.yy) - (transform.xy * transform.yx)))
+}
+
+// imageTransform maps coordinates as they appear in the file to absolute image coordinates in millimetres, applying the
+// image parameters and converting from inches if necessary
+func (gfxState *GraphicsState) imageTransform() AffineTransform {
+	origin := Point{}
+	origin.X, origin.Y = gfxState.imageParams.transformPoint(0.0, 0.0)
+	xAxis, yAxis := Point{}, Point{}
+	xAxis.X, xAxis.Y = gfxState.imageParams.transformPoint(1.0, 0.0)
+	yAxis.X, yAxis.Y = gfxState.imageParams.transformPoint(0.0, 1.0)
+
+	imageTransform := AffineTransform{
+		xx: xAxis.X - origin.X,
+		xy: yAxis.X - origin.X,
+		yx: xAxis.Y - origin.Y,
+		yy: yAxis.Y - origin.Y,
+		x0: origin.X,
+		y0: origin.Y,
+	}
+
+	if gfxState.units == UNITS_IN {
+		return imageTransform.Then(newScaleTransform(MM_PER_INCH))
+	}
+
+	return imageTransform
+}
@@ -2,38 +2,30 @@ package gerber_rs274x
 
 import (
 	"fmt"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type ApertureDefinitionParameter struct {
+	dataBlockPosition
 	paramCode      ParameterCode
 	apertureNumber int
 	apertureType   ApertureType
 	aperture       Aperture
 }
 
-func (apertureDefinition *ApertureDefinitionParameter) ProcessDataBlockToolpath(camo *CamOutput, gfxState *GraphicsState) error {
-	apertureDefinition.ProcessDataBlockSurface(nil, gfxState)
-	return nil
-}
-
 func (apertureDefinition *ApertureDefinitionParameter) DataBlockPlaceholder() {
 
 }
 
 func (apertureDefinition *ApertureDefinitionParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
-	// Remember this aperture in the graphics state for later use
+	// Remember this aperture in the graphics state for later use, along with the aperture attributes that apply to it
 	gfxState.apertures[apertureDefinition.apertureNumber] = apertureDefinition.aperture
+	gfxState.definedApertureAttributes[apertureDefinition.apertureNumber] = gfxState.apertureAttributes.copy()
 
 	return nil
 }
 
-func (apertureDefinition *ApertureDefinitionParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	// Remember this aperture in the graphics state for later use
-	gfxState.apertures[apertureDefinition.apertureNumber] = apertureDefinition.aperture
-
-	return nil
+func (apertureDefinition *ApertureDefinitionParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return apertureDefinition.ProcessDataBlockBoundsCheck(nil, gfxState)
 }
 
 func (adParam *ApertureDefinitionParameter) String() string {
@@ -2,13 +2,13 @@ package gerber_rs274x
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type ApertureMacroParameter struct {
+	dataBlockPosition
 	paramCode  ParameterCode
 	macroName  string
 	dataBlocks []ApertureMacroDataBlock
@@ -22,7 +22,7 @@ type AperturePrimitive interface {
 	ApertureMacroDataBlock
 	AperturePrimitivePlaceholder()
 	GetPrimitiveBounds(env *ExpressionEnvironment) (xMin float64, xMax float64, yMin float64, yMax float64)
-	DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error
+	getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error)
 }
 
 type ApertureMacroVariableDefinition struct {
@@ -34,21 +34,32 @@ type ApertureMacroComment struct {
 	comment string
 }
 
-func (apertureMacro *ApertureMacroParameter) ProcessDataBlockToolpath(camo *CamOutput, gfxState *GraphicsState) error {
-	gfxState.apertureMacros[apertureMacro.macroName] = apertureMacro.dataBlocks
-	return nil
-}
-
 func (apertureMacro *ApertureMacroParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
 	// Save the macro in the graphics state for use during bounds checking
 	gfxState.apertureMacros[apertureMacro.macroName] = apertureMacro.dataBlocks
 	return nil
 }
 
-func (apertureMacro *ApertureMacroParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	// Save the macro in the graphics state for use during rendering
-	gfxState.apertureMacros[apertureMacro.macroName] = apertureMacro.dataBlocks
-	return nil
+func (apertureMacro *ApertureMacroParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return apertureMacro.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+// newPrimitiveLayer builds the layer for a macro primitive.  Primitives rotate about the origin of the macro, not their own
+// center, and an exposure of 0 means the primitive erases rather than draws
+func newPrimitiveLayer(polygons []Polygon, exposure float64, rotationDegrees float64) PolygonLayer {
+	polarity := DARK_POLARITY
+	if exposure == 0.0 {
+		polarity = CLEAR_POLARITY
+	}
+
+	if rotationDegrees != 0.0 {
+		rotation := newRotationTransform(rotationDegrees * (math.Pi / 180.0))
+		for i, polygon := range polygons {
+			polygons[i] = polygon.Transform(rotation)
+		}
+	}
+
+	return PolygonLayer{polygons, polarity}
 }
 
 func (variableDefinition *ApertureMacroVariableDefinition) ApertureMacroDataBlockPlaceholder() {
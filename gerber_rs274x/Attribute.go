@@ -1,26 +1,116 @@
 package gerber_rs274x
 
-import cairo "github.com/ungerik/go-cairo"
+import (
+	"fmt"
+	"sort"
+)
 
+// Attribute is a Gerber X2 attribute block.  TF, TA and TO blocks add an attribute to the file, aperture or object attribute
+// dictionary respectively.  TD blocks delete the named attribute from the aperture and object dictionaries, or every
+// attribute in both dictionaries if no name is given
 type Attribute struct {
-	typ  string
-	name string
-	args []string
+	dataBlockPosition
+	paramCode ParameterCode
+	name      string
+	values    []string
 }
 
-func (attrib Attribute) DataBlockPlaceholder() {
+// AttributeDictionary maps attribute names (including the leading "." of standard attributes, eg. ".AperFunction") to their values
+type AttributeDictionary map[string][]string
+
+func (attrib *Attribute) DataBlockPlaceholder() {
 
 }
 
-func (attrib Attribute) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+func (attrib *Attribute) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	attrib.updateAttributeDictionaries(gfxState)
 
 	return nil
 }
 
-func (attrib Attribute) ProcessDataBlockToolpath(*CamOutput, *GraphicsState) error {
-	return nil
+func (attrib *Attribute) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return attrib.ProcessDataBlockBoundsCheck(nil, gfxState)
 }
 
-func (attrib Attribute) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	return nil
+func (attrib *Attribute) updateAttributeDictionaries(gfxState *GraphicsState) {
+	switch attrib.paramCode {
+	case TF_PARAMETER:
+		gfxState.fileAttributes[attrib.name] = attrib.values
+
+	case TA_PARAMETER:
+		gfxState.apertureAttributes[attrib.name] = attrib.values
+
+	case TO_PARAMETER:
+		gfxState.objectAttributes[attrib.name] = attrib.values
+
+	case TD_PARAMETER:
+		if len(attrib.name) == 0 {
+			gfxState.apertureAttributes = make(AttributeDictionary)
+			gfxState.objectAttributes = make(AttributeDictionary)
+		} else {
+			delete(gfxState.apertureAttributes, attrib.name)
+			delete(gfxState.objectAttributes, attrib.name)
+		}
+	}
+}
+
+func (attrib *Attribute) String() string {
+	var attribType string
+
+	switch attrib.paramCode {
+	case TF_PARAMETER:
+		attribType = "TF"
+
+	case TA_PARAMETER:
+		attribType = "TA"
+
+	case TO_PARAMETER:
+		attribType = "TO"
+
+	case TD_PARAMETER:
+		attribType = "TD"
+
+	default:
+		attribType = "Unknown"
+	}
+
+	return fmt.Sprintf("{%s, Name: %s, Values: %v}", attribType, attrib.name, attrib.values)
+}
+
+// Get returns the values of the named attribute, and whether the attribute is in the dictionary at all
+func (dictionary AttributeDictionary) Get(name string) (values []string, found bool) {
+	values, found = dictionary[name]
+	return values, found
+}
+
+// GetFirst returns the first value of the named attribute, or an empty string if the attribute isn't in the dictionary
+// or has no values
+func (dictionary AttributeDictionary) GetFirst(name string) string {
+	if values := dictionary[name]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// GetNames returns the names of every attribute in the dictionary, in sorted order
+func (dictionary AttributeDictionary) GetNames() []string {
+	names := make([]string, 0, len(dictionary))
+	for name := range dictionary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// copy takes a snapshot of the dictionary, so that objects keep the attributes that were current when they were created.
+// The value slices are never modified once parsed, so they can be shared
+func (dictionary AttributeDictionary) copy() AttributeDictionary {
+	dictionaryCopy := make(AttributeDictionary, len(dictionary))
+	for name, values := range dictionary {
+		dictionaryCopy[name] = values
+	}
+
+	return dictionaryCopy
 }
@@ -1,9 +1,21 @@
 package gerber_rs274x
 
-import "io"
+import (
+	"fmt"
+	"io"
+)
 
 type CamState GraphicsState
 
+// ToolpathMode chooses how copper is exposed.  Vector mode fills each feature in turn, while raster mode scans the whole
+// flattened image line by line
+type ToolpathMode int
+
+const (
+	VECTOR_TOOLPATH_MODE ToolpathMode = iota
+	RASTER_TOOLPATH_MODE
+)
+
 type CamOutput struct {
 	wrt            io.WriteCloser
 	toolWidth      float64
@@ -11,8 +23,22 @@ type CamOutput struct {
 	power          int // laser power
 	feedrate       int // laser power
 	translateScale func(float64, float64) (float64, float64)
+
+	// Curved aperture outlines are filled as polygons whose chords stray no further than this from the true curve
+	chordTolerance float64
+
+	// Regions are collected as the image is drawn, and only filled at the end of it
+	regionLayers []PolygonLayer
+
+	// Negative images are filled as a whole when they're begun, rather than object by object
+	negativeImage bool
+
+	mode      ToolpathMode
+	rasterDPI float64
 }
 
+// NewCamOutput sets up a laser toolpath.  The tool width is in millimetres, and translateScale is given image coordinates
+// in millimetres (with the image parameters applied), in both vector and raster mode.  It returns machine coordinates
 func NewCamOutput(
 	wrt io.WriteCloser,
 	feedrate int,
@@ -29,5 +55,34 @@ func NewCamOutput(
 		power:          power,
 		feedrate:       feedrate,
 		translateScale: translateScale,
+		chordTolerance: toolWidth / 10.0,
+	}
+}
+
+// SetChordTolerance sets how far (in millimetres) the filled outline of a curved aperture may stray from the true curve.
+// It defaults to a tenth of the tool width
+func (camo *CamOutput) SetChordTolerance(tolerance float64) error {
+	if tolerance <= 0.0 {
+		return fmt.Errorf("Chord tolerance must be positive, got %f", tolerance)
+	}
+	camo.chordTolerance = tolerance
+
+	return nil
+}
+
+// SetRasterMode switches to scanline raster output, with scanlines and pixels spaced at the given resolution (in dots
+// per inch).  Raster output drives the laser with dynamic power (M4), so it needs a controller that supports it
+func (camo *CamOutput) SetRasterMode(dpi float64) error {
+	if dpi <= 0.0 {
+		return fmt.Errorf("Raster resolution must be positive, got %f DPI", dpi)
 	}
+	camo.mode = RASTER_TOOLPATH_MODE
+	camo.rasterDPI = dpi
+
+	return nil
+}
+
+// SetVectorMode switches back to filling each feature in turn, which is the default
+func (camo *CamOutput) SetVectorMode() {
+	camo.mode = VECTOR_TOOLPATH_MODE
 }
@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type CenterLinePrimitive struct {
@@ -27,9 +26,14 @@ func (primitive *CenterLinePrimitive) GetPrimitiveBounds(env *ExpressionEnvironm
 	return 0.0, 0.0, 0.0, 0.0
 }
 
-func (primitive *CenterLinePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
-	return nil
+func (primitive *CenterLinePrimitive) getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error) {
+	radiusX := primitive.width.EvaluateExpression(env) / 2.0
+	radiusY := primitive.height.EvaluateExpression(env) / 2.0
+	centerX := primitive.centerX.EvaluateExpression(env)
+	centerY := primitive.centerY.EvaluateExpression(env)
+	polygon := rectanglePolygon(centerX-radiusX, centerX+radiusX, centerY-radiusY, centerY+radiusY)
+
+	return newPrimitiveLayer([]Polygon{polygon}, primitive.exposure.EvaluateExpression(env), primitive.rotationAngle.EvaluateExpression(env)), nil
 }
 
 func (primitive *CenterLinePrimitive) String() string {
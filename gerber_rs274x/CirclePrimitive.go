@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type CirclePrimitive struct {
@@ -28,9 +27,11 @@ func (primitive *CirclePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment)
 	return centerX - radius, centerX + radius, centerY - radius, centerY + radius
 }
 
-func (primitive *CirclePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
-	return nil
+func (primitive *CirclePrimitive) getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error) {
+	center := Point{primitive.centerX.EvaluateExpression(env), primitive.centerY.EvaluateExpression(env)}
+	radius := primitive.diameter.EvaluateExpression(env) / 2.0
+
+	return newPrimitiveLayer([]Polygon{circlePolygon(center, radius, tolerance)}, primitive.exposure.EvaluateExpression(env), 0.0), nil
 }
 
 func (primitive *CirclePrimitive) String() string {
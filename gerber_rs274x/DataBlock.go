@@ -1,10 +1,9 @@
 package gerber_rs274x
 
-import cairo "github.com/ungerik/go-cairo"
-
 type DataBlock interface {
 	DataBlockPlaceholder()
+	GetPosition() SourcePosition
+	setPosition(position SourcePosition)
 	ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error
-	ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error
-	ProcessDataBlockToolpath(*CamOutput, *GraphicsState) error
+	ProcessDataBlockInterpret(gfxState *GraphicsState) error
 }
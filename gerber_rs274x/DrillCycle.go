@@ -0,0 +1,108 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+)
+
+// The spindle runs at this speed (in RPM) when neither the tool nor the CAM settings give one
+const DEFAULT_SPINDLE_SPEED int = 10000
+
+// getSpindleSpeed returns the spindle speed set in CAM settings, or the default if it isn't set
+func getSpindleSpeed(speed int) int {
+	if speed <= 0 {
+		return DEFAULT_SPINDLE_SPEED
+	}
+
+	return speed
+}
+
+// Between pecks, the drill drops back quickly to this far (in millimetres) above the bottom of the hole
+const DRILL_PECK_CLEARANCE float64 = 0.2
+
+// DrlToolSettings overrides the spindle speed (in RPM) and plunge feed (in millimetres per minute) of a tool, with zero
+// leaving the setting alone
+type DrlToolSettings struct {
+	SpindleSpeed int
+	Feed         int
+}
+
+// getToolSpeeds works out the spindle speed and plunge feed for the current tool
+func (drl *DrlData) getToolSpeeds(cam *DrlCAM) (speed int, feed int) {
+	speed, feed = getSpindleSpeed(cam.SpindleSpeed), cam.DrillF
+
+	if tool := drl.getTool(cam.Tooln); tool != nil {
+		speedScale := cam.ToolSpeedScale
+		if speedScale <= 0.0 {
+			speedScale = 1.0
+		}
+		if tool.speed > 0.0 {
+			speed = int(math.Round(tool.speed * speedScale))
+		}
+		if tool.feed > 0.0 {
+			feed = int(math.Round(tool.feed))
+		}
+
+		if tool.bit != nil && tool.bit.SpindleSpeed > 0 {
+			speed = tool.bit.SpindleSpeed
+		}
+		if tool.bit != nil && tool.bit.Feed > 0 {
+			feed = tool.bit.Feed
+		}
+	}
+
+	if settings, found := cam.ToolTable[cam.Tooln]; found {
+		if settings.SpindleSpeed > 0 {
+			speed = settings.SpindleSpeed
+		}
+		if settings.Feed > 0 {
+			feed = settings.Feed
+		}
+	}
+
+	return //
+}
+
+func (drl *DrlData) startSpindle(cam *DrlCAM, speed int) {
+	if cam.spindleOn {
+		return
+	}
+	fmt.Fprintf(cam.Wrt, "M3S%d\n", speed)
+	cam.spindleOn = true
+}
+
+func (drl *DrlData) stopSpindle(cam *DrlCAM) {
+	drl.endCannedCycle(cam)
+	if !cam.spindleOn {
+		return
+	}
+	fmt.Fprintf(cam.Wrt, "M5\n")
+	cam.spindleOn = false
+}
+
+// genCannedHole drills a hole with a G81 (or G83 pecking) cycle.  Once a cycle has started, the hits after it only
+// need their position, until something else ends the cycle
+func (drl *DrlData) genCannedHole(cam *DrlCAM, x float64, y float64, speed int, feed int) {
+	w := cam.Wrt
+	if cam.cycleOn {
+		fmt.Fprintf(w, "X%fY%f\n", x, y)
+		return
+	}
+
+	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+	drl.startSpindle(cam, speed)
+	if cam.PeckDepth > 0.0 {
+		fmt.Fprintf(w, "G98G83X%fY%fZ%fR%fQ%fF%d\n", x, y, cam.DrillZ, cam.SafeZ, cam.PeckDepth, feed)
+	} else {
+		fmt.Fprintf(w, "G98G81X%fY%fZ%fR%fF%d\n", x, y, cam.DrillZ, cam.SafeZ, feed)
+	}
+	cam.cycleOn = true
+}
+
+func (drl *DrlData) endCannedCycle(cam *DrlCAM) {
+	if !cam.cycleOn {
+		return
+	}
+	fmt.Fprintf(cam.Wrt, "G80\n")
+	cam.cycleOn = false
+}
@@ -0,0 +1,120 @@
+package gerber_rs274x
+
+import (
+	"math"
+)
+
+// 2-opt improvement stops after this many passes over the hits, even if it's still finding shorter orders, so huge
+// drill files can't take forever
+const MAX_DRILL_ORDER_PASSES int = 50
+
+// OptimizeDrillOrder reorders the hits drilled with each tool to cut down on rapid moves between them, returning the
+// estimated rapid distance (in millimetres) before and after.  The order is built up by always going to the nearest
+// hit left, then improved by reversing stretches of it (2-opt) wherever that makes it shorter.  Only runs of plain hits
+// are reordered, and slots and routed holes stay where they are.  Home is where the tool starts after each tool
+// change, in drill file coordinates
+func (drl *DrlData) OptimizeDrillOrder(homeX float64, homeY float64) (before float64, after float64) {
+	home := Point{homeX, homeY}
+	before = drl.getRapidDistance(home)
+
+	position := home
+	for i := 0; i < len(drl.Steps); {
+		st := drl.Steps[i]
+		if st.typ != "D" {
+			position = getStepPosition(st, position, home)
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(drl.Steps) && drl.Steps[end].typ == "D" {
+			end++
+		}
+
+		hits := drl.Steps[i:end]
+		orderHits(hits, position)
+		position = Point{hits[len(hits)-1].x, hits[len(hits)-1].y}
+		i = end
+	}
+
+	after = drl.getRapidDistance(home)
+	return //
+}
+
+// getStepPosition returns where the tool is after a step
+func getStepPosition(st *Step, position Point, home Point) Point {
+	switch st.typ {
+	case "T":
+		return home
+	case "S":
+		return Point{st.x2, st.y2}
+	case "P", "U":
+		return position
+	default:
+		return Point{st.x, st.y}
+	}
+}
+
+// getRapidDistance estimates how far the tool moves between cuts, going home at every tool change
+func (drl *DrlData) getRapidDistance(home Point) float64 {
+	distance := 0.0
+	position := home
+	for _, st := range drl.Steps {
+		switch st.typ {
+		case "D", "S", "G":
+			distance += math.Hypot(st.x-position.X, st.y-position.Y)
+		}
+		position = getStepPosition(st, position, home)
+	}
+	return distance
+}
+
+// orderHits reorders hits in place, into a short open path from the start point
+func orderHits(hits []*Step, start Point) {
+	distance := func(a *Step, b *Step) float64 {
+		return math.Hypot(a.x-b.x, a.y-b.y)
+	}
+
+	// The start is treated as an extra hit at the front that never moves
+	path := make([]*Step, 0, len(hits)+1)
+	path = append(path, &Step{x: start.X, y: start.Y})
+	remaining := append([]*Step(nil), hits...)
+	for len(remaining) > 0 {
+		last := path[len(path)-1]
+		nearest := 0
+		for j := 1; j < len(remaining); j++ {
+			if distance(last, remaining[j]) < distance(last, remaining[nearest]) {
+				nearest = j
+			}
+		}
+		path = append(path, remaining[nearest])
+		remaining[nearest] = remaining[len(remaining)-1]
+		remaining = remaining[:len(remaining)-1]
+	}
+
+	// Reversing path[i:j+1] swaps the links into i and out of j for shorter ones.  The path is open, so reversing the
+	// stretch up to the end only changes the link into it
+	for pass := 0; pass < MAX_DRILL_ORDER_PASSES; pass++ {
+		improved := false
+		for i := 1; i < len(path)-1; i++ {
+			for j := i + 1; j < len(path); j++ {
+				change := distance(path[i-1], path[j]) - distance(path[i-1], path[i])
+				if j+1 < len(path) {
+					change += distance(path[i], path[j+1]) - distance(path[j], path[j+1])
+				}
+
+				if change < -1e-9 {
+					for a, b := i, j; a < b; a, b = a+1, b-1 {
+						path[a], path[b] = path[b], path[a]
+					}
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	copy(hits, path[1:])
+}
@@ -0,0 +1,146 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"testing"
+)
+
+func makeHits(points ...Point) []*Step {
+	hits := make([]*Step, len(points))
+	for i, point := range points {
+		hits[i] = &Step{typ: "D", x: point.X, y: point.Y}
+	}
+	return hits
+}
+
+func getHitsLength(hits []*Step, start Point) float64 {
+	length := 0.0
+	position := start
+	for _, hit := range hits {
+		length += math.Hypot(hit.x-position.X, hit.y-position.Y)
+		position = Point{hit.x, hit.y}
+	}
+	return length
+}
+
+func TestOrderHitsLine(t *testing.T) {
+	hits := makeHits(Point{3, 0}, Point{1, 0}, Point{4, 0}, Point{2, 0})
+	orderHits(hits, Point{0, 0})
+
+	for i, hit := range hits {
+		checkPoint(t, "Hit", hit.x, hit.y, float64(i+1), 0.0)
+	}
+}
+
+func TestOrderHitsTwoOpt(t *testing.T) {
+	// Going to the nearest hit first leads to 1, then back past the start to -2 and all the way out to 4.5, which is
+	// 10.5 long.  Reversing the first two hits gets it down to 8.5
+	hits := makeHits(Point{1, 0}, Point{-2, 0}, Point{4.5, 0})
+	orderHits(hits, Point{0, 0})
+
+	want := []float64{-2, 1, 4.5}
+	for i, hit := range hits {
+		checkPoint(t, "Hit", hit.x, hit.y, want[i], 0.0)
+	}
+	if length := getHitsLength(hits, Point{0, 0}); math.Abs(length-8.5) > drillTestTolerance {
+		t.Errorf("Path is %f long, want 8.5", length)
+	}
+}
+
+func TestOrderHitsLocallyOptimal(t *testing.T) {
+	// A scattered set of hits, in an order that crosses over itself a lot
+	var points []Point
+	for i := 0; i < 40; i++ {
+		points = append(points, Point{float64((i * 17) % 23), float64((i * 11) % 13)})
+	}
+	hits := makeHits(points...)
+	start := Point{0, 0}
+	before := getHitsLength(hits, start)
+
+	orderHits(hits, start)
+	after := getHitsLength(hits, start)
+	if after > before {
+		t.Errorf("Ordering made the path longer, from %f to %f", before, after)
+	}
+
+	// Every hit is still there exactly once
+	seen := make(map[Point]int)
+	for _, point := range points {
+		seen[point]++
+	}
+	for _, hit := range hits {
+		seen[Point{hit.x, hit.y}]--
+	}
+	for point, count := range seen {
+		if count != 0 {
+			t.Errorf("Hit at (%f, %f) is out by %d", point.X, point.Y, count)
+		}
+	}
+
+	// No reversal of a stretch of the path makes it any shorter
+	for i := 0; i < len(hits); i++ {
+		for j := i + 1; j < len(hits); j++ {
+			reversed := append([]*Step(nil), hits...)
+			for a, b := i, j; a < b; a, b = a+1, b-1 {
+				reversed[a], reversed[b] = reversed[b], reversed[a]
+			}
+			if length := getHitsLength(reversed, start); length < after-1e-6 {
+				t.Fatalf("Reversing hits %d to %d shortens the path from %f to %f", i, j, after, length)
+			}
+		}
+	}
+}
+
+func TestOptimizeDrillOrder(t *testing.T) {
+	drl := parseTestDrl(t, `M48
+METRIC
+T1C1.0
+T2C2.0
+%
+T1
+X3.0Y0
+X1.0Y0
+X2.0Y0
+X5.0Y5.0G85X6.0Y5.0
+X8.0Y0
+X7.0Y0
+T2
+X2.0Y2.0
+X1.0Y1.0
+M30
+`)
+
+	before, after := drl.OptimizeDrillOrder(0.0, 0.0)
+	if after >= before {
+		t.Errorf("Rapid distance went from %f to %f, want it shorter", before, after)
+	}
+
+	// Each run of hits is reordered on its own, starting from where the tool was, and the slot stays between them
+	want := []struct {
+		typ  string
+		x, y float64
+	}{
+		{"T", 0, 0},
+		{"D", 1, 0},
+		{"D", 2, 0},
+		{"D", 3, 0},
+		{"S", 5, 5},
+		{"D", 7, 0},
+		{"D", 8, 0},
+		{"T", 0, 0},
+		{"D", 1, 1},
+		{"D", 2, 2},
+	}
+	if len(drl.Steps) != len(want) {
+		t.Fatalf("Got %d steps, want %d", len(drl.Steps), len(want))
+	}
+	for i, step := range drl.Steps {
+		if step.typ != want[i].typ {
+			t.Errorf("Step %d is %s, want %s", i, step.typ, want[i].typ)
+			continue
+		}
+		if step.typ != "T" {
+			checkPoint(t, "Step", step.x, step.y, want[i].x, want[i].y)
+		}
+	}
+}
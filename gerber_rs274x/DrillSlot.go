@@ -0,0 +1,173 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+)
+
+// getRoutePath flattens the moves of a routed section into the points the centre of the tool follows, starting from
+// where the tool plunged.  Arcs are approximated by chords that never stray more than a fiftieth of the tool's diameter
+// from the true curve
+func (drl *DrlData) getRoutePath(cam *DrlCAM, start Point, route []*Step) []Point {
+	tolerance := 0.01
+	if tool := drl.getTool(cam.Tooln); tool != nil && tool.size > 0.0 {
+		tolerance = tool.size / 50.0
+	}
+
+	path := []Point{start}
+	previous := start
+	for _, st := range route {
+		end := Point{st.x, st.y}
+
+		if st.typ == "C" || st.typ == "A" {
+			center := Point{st.cx, st.cy}
+			startAngle := math.Atan2(previous.Y-center.Y, previous.X-center.X)
+			sweepAngle := math.Atan2(end.Y-center.Y, end.X-center.X) - startAngle
+
+			// An arc that ends where it starts is a full circle
+			if st.typ == "A" && sweepAngle <= 1e-9 {
+				sweepAngle += TWO_PI
+			} else if st.typ == "C" && sweepAngle >= -1e-9 {
+				sweepAngle -= TWO_PI
+			}
+
+			points := arcPoints(center, math.Hypot(previous.X-center.X, previous.Y-center.Y), startAngle, sweepAngle, tolerance)
+			path = append(path, points[1:len(points)-1]...)
+		}
+
+		if st.typ != "G" {
+			path = append(path, end)
+		}
+		previous = end
+	}
+
+	return path
+}
+
+// getPathLength adds up the lengths of the lines joining the points of a path
+func getPathLength(path []Point) float64 {
+	length := 0.0
+	for i := 1; i < len(path); i++ {
+		length += math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y)
+	}
+	return length
+}
+
+// getSlotHits spreads hits evenly along a path, no more than the spacing apart and including both ends.  A closed
+// path only gets one hit where it starts and ends
+func getSlotHits(path []Point, spacing float64) []Point {
+	length := getPathLength(path)
+	if length < 1e-9 || spacing <= 0.0 {
+		return path[:1]
+	}
+
+	count := int(math.Ceil((length / spacing) - 1e-9))
+	hits := make([]Point, 0, count+1)
+	hits = append(hits, path[0])
+
+	i, distance := 1, 0.0
+	for n := 1; n <= count; n++ {
+		target := length * float64(n) / float64(count)
+
+		for i < len(path)-1 && distance+math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y) < target {
+			distance += math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y)
+			i++
+		}
+
+		segmentLength := math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y)
+		t := 1.0
+		if segmentLength > 0.0 {
+			t = math.Min(1.0, (target-distance)/segmentLength)
+		}
+		hits = append(hits, Point{path[i-1].X + (t * (path[i].X - path[i-1].X)), path[i-1].Y + (t * (path[i].Y - path[i-1].Y))})
+	}
+
+	if path[0] == path[len(path)-1] {
+		hits = hits[:len(hits)-1]
+	}
+	return hits
+}
+
+// genSlot cuts a slot or routed hole along a path, milling it with an end mill or drilling it as a row of overlapping
+// hits with a drill bit
+func (drl *DrlData) genSlot(cam *DrlCAM, path []Point) {
+	tool := drl.getTool(cam.Tooln)
+	if tool == nil {
+		return
+	}
+
+	endMill := cam.EndMill
+	if tool.bit != nil {
+		endMill = tool.bit.EndMill
+	}
+
+	if !endMill || getPathLength(path) < 1e-9 {
+		for _, hit := range getSlotHits(path, tool.size*(1.0-cam.SlotOverlap)) {
+			drl.genDrillHole(cam, &Step{typ: "D", tooln: cam.Tooln, x: hit.X, y: hit.Y})
+		}
+		return
+	}
+
+	w := cam.Wrt
+	speed, plungeFeed := drl.getToolSpeeds(cam)
+	feed := cam.SlotF
+	if feed <= 0 {
+		feed = plungeFeed
+	}
+
+	drl.endCannedCycle(cam)
+	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+	x, y := cam.TranslateScale(path[0].X, path[0].Y)
+	fmt.Fprintf(w, "G00X%fY%f\n", x, y)
+	drl.startSpindle(cam, speed)
+
+	// Open paths are cut back and forth, while closed ones go round the same way every pass
+	closed := path[0] == path[len(path)-1]
+	depths := getPassDepths(cam.DrillZ, cam.SlotPassDepth)
+	if cam.SlotRamp {
+		fmt.Fprintf(w, "G01Z%fF%d\n", 0.0, plungeFeed)
+	}
+
+	currentZ := 0.0
+	for _, passZ := range depths {
+		if !cam.SlotRamp {
+			fmt.Fprintf(w, "G01Z%fF%d\n", passZ, plungeFeed)
+			currentZ = passZ
+		}
+		drl.genSlotPass(cam, path, currentZ, passZ, feed)
+		currentZ = passZ
+
+		if !closed {
+			path = Polygon(path).Reverse()
+		}
+	}
+
+	// Ramping leaves a sloping floor on the last pass, which one more pass at full depth cleans up
+	if cam.SlotRamp {
+		drl.genSlotPass(cam, path, currentZ, currentZ, feed)
+	}
+
+	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+	if !cam.KeepSpindleOn {
+		drl.stopSpindle(cam)
+	}
+}
+
+// genSlotPass moves the tool along a path, going down steadily from startZ to endZ on the way
+func (drl *DrlData) genSlotPass(cam *DrlCAM, path []Point, startZ float64, endZ float64, feed int) {
+	w := cam.Wrt
+	length := getPathLength(path)
+
+	distance := 0.0
+	for i := 1; i < len(path); i++ {
+		x, y := cam.TranslateScale(path[i].X, path[i].Y)
+		if startZ == endZ {
+			fmt.Fprintf(w, "G01X%fY%fF%d\n", x, y, feed)
+			continue
+		}
+
+		distance += math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y)
+		z := startZ + ((endZ - startZ) * distance / length)
+		fmt.Fprintf(w, "G01X%fY%fZ%fF%d\n", x, y, z, feed)
+	}
+}
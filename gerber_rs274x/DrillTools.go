@@ -0,0 +1,234 @@
+package gerber_rs274x
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Holes are drilled with a bit up to this far (in millimetres) from their size, unless the tool library sets its own
+// tolerance
+const DEFAULT_DRILL_SIZE_TOLERANCE float64 = 0.05
+
+// DrillBit is one of the bits in the shop.  End mills can cut sideways, so they're used for slots and for holes too
+// big for any drill bit.  The spindle speed (in RPM) and feed (in millimetres per minute) are optional, and override the
+// drill file's tool parameters
+type DrillBit struct {
+	Name         string  `json:"name"`
+	Diameter     float64 `json:"diameter"`
+	EndMill      bool    `json:"endMill"`
+	SpindleSpeed int     `json:"spindleSpeed"`
+	Feed         int     `json:"feed"`
+}
+
+// ToolLibrary lists the bits the shop owns, and how far (in millimetres) a hole may be from the size of the bit
+// that drills it.  As JSON:
+//
+//	{"tolerance": 0.05, "bits": [{"name": "0.8 mm drill", "diameter": 0.8}, {"diameter": 2.0, "endMill": true}]}
+type ToolLibrary struct {
+	Tolerance float64    `json:"tolerance"`
+	Bits      []DrillBit `json:"bits"`
+}
+
+// ToolMapping reports what became of one of the drill file's tools.  Bit is nil when no bit fits the tool, which is
+// then left as it is.  Milled tools are too big for every drill bit, so their holes are milled as circles with an end
+// mill.  Tools mapped to the same bit are merged, and all of their holes end up under NewTooln
+type ToolMapping struct {
+	Tooln    int
+	Size     float64
+	Bit      *DrillBit
+	NewTooln int
+	Milled   bool
+}
+
+func (mapping ToolMapping) String() string {
+	switch {
+	case mapping.Bit == nil:
+		return fmt.Sprintf("T%d %.3f mm: no bit fits", mapping.Tooln, mapping.Size)
+	case mapping.Milled:
+		return fmt.Sprintf("T%d %.3f mm: milled with %s (T%d)", mapping.Tooln, mapping.Size, mapping.Bit.getName(), mapping.NewTooln)
+	default:
+		return fmt.Sprintf("T%d %.3f mm: drilled with %s (T%d)", mapping.Tooln, mapping.Size, mapping.Bit.getName(), mapping.NewTooln)
+	}
+}
+
+func (bit *DrillBit) getName() string {
+	if bit.Name != "" {
+		return bit.Name
+	}
+	if bit.EndMill {
+		return fmt.Sprintf("%.3f mm end mill", bit.Diameter)
+	}
+	return fmt.Sprintf("%.3f mm drill", bit.Diameter)
+}
+
+// ReadToolLibrary reads a tool library from JSON
+func ReadToolLibrary(rdr io.Reader) (*ToolLibrary, error) {
+	library := new(ToolLibrary)
+	if err := json.NewDecoder(rdr).Decode(library); err != nil {
+		return nil, fmt.Errorf("Unable to read tool library: %s", err.Error())
+	}
+
+	if library.Tolerance < 0.0 {
+		return nil, fmt.Errorf("Tool library tolerance can't be negative, got %f", library.Tolerance)
+	}
+	for _, bit := range library.Bits {
+		if bit.Diameter <= 0.0 {
+			return nil, fmt.Errorf("Bit %s must have a positive diameter, got %f", bit.Name, bit.Diameter)
+		}
+	}
+
+	return library, nil
+}
+
+// MapTools assigns each of the drill file's tools to a bit from the library.  Holes go to the nearest drill bit within
+// the tolerance, and holes bigger than every drill bit are milled as circles with the biggest end mill that fits
+// inside them (as are slots that wide, round their outline).  Tools are resized to their bits, and tools sharing a
+// bit are merged into one, so each bit is only loaded once.  Routed sections are left to follow their own paths
+func (drl *DrlData) MapTools(library *ToolLibrary) []ToolMapping {
+	tolerance := library.Tolerance
+	if tolerance <= 0.0 {
+		tolerance = DEFAULT_DRILL_SIZE_TOLERANCE
+	}
+
+	largestDrill := 0.0
+	for _, bit := range library.Bits {
+		if !bit.EndMill {
+			largestDrill = math.Max(largestDrill, bit.Diameter)
+		}
+	}
+
+	mappings := make([]ToolMapping, 0, len(drl.Tools))
+	bitTools := make(map[*DrillBit]int, len(library.Bits))
+	newToolns := make(map[int]int, len(drl.Tools))
+	for tooln, tool := range drl.Tools {
+		if tool == nil {
+			continue
+		}
+		mapping := ToolMapping{Tooln: tooln, Size: tool.size, NewTooln: tooln}
+
+		for i := range library.Bits {
+			bit := &library.Bits[i]
+			if bit.EndMill || math.Abs(bit.Diameter-tool.size) > tolerance {
+				continue
+			}
+			if mapping.Bit == nil || math.Abs(bit.Diameter-tool.size) < math.Abs(mapping.Bit.Diameter-tool.size) {
+				mapping.Bit = bit
+			}
+		}
+
+		if mapping.Bit == nil && tool.size > largestDrill+tolerance {
+			for i := range library.Bits {
+				bit := &library.Bits[i]
+				if bit.EndMill && bit.Diameter < tool.size && (mapping.Bit == nil || bit.Diameter > mapping.Bit.Diameter) {
+					mapping.Bit = bit
+				}
+			}
+			if mapping.Bit != nil {
+				mapping.Milled = true
+				drl.millToolHoles(tooln, (tool.size-mapping.Bit.Diameter)/2.0)
+			}
+		}
+
+		if mapping.Bit != nil {
+			// The first tool to use a bit keeps its number, and the rest are merged into it
+			if firstTooln, found := bitTools[mapping.Bit]; found {
+				mapping.NewTooln = firstTooln
+				drl.Tools[tooln] = nil
+			} else {
+				bitTools[mapping.Bit] = tooln
+				tool.size = mapping.Bit.Diameter
+				tool.bit = mapping.Bit
+			}
+			newToolns[tooln] = mapping.NewTooln
+		}
+
+		mappings = append(mappings, mapping)
+	}
+
+	for _, st := range drl.Steps {
+		if newTooln, found := newToolns[st.tooln]; found {
+			st.tooln = newTooln
+		}
+	}
+	drl.groupToolSections()
+
+	return mappings
+}
+
+// millToolHoles turns a tool's hits and slots into routes that go round them, radius further out than their middle.
+// Hits become full circles, and slots go round their rounded outline
+func (drl *DrlData) millToolHoles(tooln int, radius float64) {
+	steps := make([]*Step, 0, len(drl.Steps))
+	for _, st := range drl.Steps {
+		if st.tooln != tooln || (st.typ != "D" && st.typ != "S") {
+			steps = append(steps, st)
+			continue
+		}
+
+		var route []*Step
+		if st.typ == "D" {
+			start := &Step{typ: "G", x: st.x + radius, y: st.y}
+			route = []*Step{start, {typ: "P", x: start.x, y: start.y}, {typ: "A", x: start.x, y: start.y, cx: st.x, cy: st.y}}
+		} else {
+			// Counterclockwise round the slot: up its right side, round the far end, back down its left side, and
+			// round the near end
+			length := math.Hypot(st.x2-st.x, st.y2-st.y)
+			nx, ny := 0.0, radius
+			if length > 0.0 {
+				nx, ny = -(st.y2-st.y)*radius/length, (st.x2-st.x)*radius/length
+			}
+			start := &Step{typ: "G", x: st.x - nx, y: st.y - ny}
+			route = []*Step{
+				start,
+				{typ: "P", x: start.x, y: start.y},
+				{typ: "L", x: st.x2 - nx, y: st.y2 - ny},
+				{typ: "A", x: st.x2 + nx, y: st.y2 + ny, cx: st.x2, cy: st.y2},
+				{typ: "L", x: st.x + nx, y: st.y + ny},
+				{typ: "A", x: start.x, y: start.y, cx: st.x, cy: st.y},
+			}
+		}
+		route = append(route, &Step{typ: "U", x: route[0].x, y: route[0].y})
+
+		for _, routeStep := range route {
+			routeStep.tooln = tooln
+		}
+		steps = append(steps, route...)
+	}
+	drl.Steps = steps
+}
+
+// groupToolSections brings together all of the steps done with each tool, so that every tool is only changed to once.
+// Tools keep the order they were first used in, apart from unloading the tool (T0), which stays at the end
+func (drl *DrlData) groupToolSections() {
+	sections := make(map[int][]*Step, len(drl.Tools))
+	order := make([]int, 0, len(drl.Tools))
+
+	var leading []*Step
+	for _, st := range drl.Steps {
+		if st.typ == "T" {
+			if _, found := sections[st.tooln]; !found {
+				sections[st.tooln] = []*Step{st}
+				order = append(order, st.tooln)
+			}
+			continue
+		}
+		if _, found := sections[st.tooln]; found {
+			sections[st.tooln] = append(sections[st.tooln], st)
+		} else {
+			leading = append(leading, st)
+		}
+	}
+
+	sort.SliceStable(order, func(i int, j int) bool {
+		return order[i] != 0 && order[j] == 0
+	})
+
+	steps := leading
+	for _, tooln := range order {
+		steps = append(steps, sections[tooln]...)
+	}
+	drl.Steps = steps
+}
@@ -0,0 +1,232 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func readTestLibrary(t *testing.T, text string) *ToolLibrary {
+	t.Helper()
+
+	library, err := ReadToolLibrary(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ReadToolLibrary failed: %v", err)
+	}
+	return library
+}
+
+func TestReadToolLibraryErrors(t *testing.T) {
+	tests := []string{
+		`{"bits": [`,
+		`{"tolerance": -0.1, "bits": [{"diameter": 0.8}]}`,
+		`{"bits": [{"name": "broken", "diameter": 0}]}`,
+		`{"bits": [{"diameter": -1.0}]}`,
+	}
+
+	for _, text := range tests {
+		if _, err := ReadToolLibrary(strings.NewReader(text)); err == nil {
+			t.Errorf("ReadToolLibrary(%q) should fail", text)
+		}
+	}
+}
+
+func TestMapToolsMerging(t *testing.T) {
+	drl := parseTestDrl(t, `M48
+METRIC
+T1C0.79
+T2C1.0
+T3C0.81
+T4C1.5
+%
+T1
+X1.0Y1.0
+T2
+X2.0Y2.0
+T3
+X3.0Y3.0
+T4
+X4.0Y4.0
+T1
+X5.0Y5.0
+M30
+`)
+	library := readTestLibrary(t, `{"bits": [{"diameter": 0.8}, {"diameter": 1.0}]}`)
+
+	mappings := drl.MapTools(library)
+	want := []struct {
+		tooln    int
+		bit      float64
+		newTooln int
+	}{
+		{1, 0.8, 1},
+		{2, 1.0, 2},
+		{3, 0.8, 1},
+		{4, 0.0, 4},
+	}
+	if len(mappings) != len(want) {
+		t.Fatalf("Got %d mappings, want %d", len(mappings), len(want))
+	}
+	for i, mapping := range mappings {
+		if mapping.Tooln != want[i].tooln || mapping.NewTooln != want[i].newTooln || mapping.Milled {
+			t.Errorf("Mapping %d is %s, want T%d as T%d", i, mapping, want[i].tooln, want[i].newTooln)
+		}
+		switch {
+		case want[i].bit == 0.0 && mapping.Bit != nil:
+			t.Errorf("T%d shouldn't have a bit, got %s", mapping.Tooln, mapping.Bit.getName())
+		case want[i].bit != 0.0 && (mapping.Bit == nil || mapping.Bit.Diameter != want[i].bit):
+			t.Errorf("T%d should have the %.3f mm bit, got %s", mapping.Tooln, want[i].bit, mapping)
+		}
+	}
+
+	// Merged tools go away, and the rest take on the size of their bits, apart from the one with no bit
+	if drl.Tools[3] != nil {
+		t.Errorf("T3 should have been merged away")
+	}
+	for tooln, size := range map[int]float64{1: 0.8, 2: 1.0, 4: 1.5} {
+		if drl.Tools[tooln].size != size {
+			t.Errorf("T%d is %f mm, want %f", tooln, drl.Tools[tooln].size, size)
+		}
+	}
+
+	// Every hole done with a bit is drilled in one section, so the bit is only loaded once
+	wantSteps := []struct {
+		typ   string
+		tooln int
+		x     float64
+	}{
+		{"T", 1, 0},
+		{"D", 1, 1},
+		{"D", 1, 3},
+		{"D", 1, 5},
+		{"T", 2, 0},
+		{"D", 2, 2},
+		{"T", 4, 0},
+		{"D", 4, 4},
+	}
+	if len(drl.Steps) != len(wantSteps) {
+		t.Fatalf("Got %d steps, want %d", len(drl.Steps), len(wantSteps))
+	}
+	for i, step := range drl.Steps {
+		if step.typ != wantSteps[i].typ || step.tooln != wantSteps[i].tooln || (step.typ == "D" && step.x != wantSteps[i].x) {
+			t.Errorf("Step %d is %s with T%d at x %f, want %s with T%d at x %f", i, step.typ, step.tooln, step.x,
+				wantSteps[i].typ, wantSteps[i].tooln, wantSteps[i].x)
+		}
+	}
+}
+
+func TestMapToolsTolerance(t *testing.T) {
+	drl := parseTestDrl(t, "M48\nMETRIC\nT1C0.9\n%\nT1\nX1.0Y1.0\nM30\n")
+
+	// Too far from the bit for the default tolerance, but close enough for the library's own
+	if mappings := drl.MapTools(&ToolLibrary{Bits: []DrillBit{{Diameter: 1.0}}}); mappings[0].Bit != nil {
+		t.Errorf("0.9 mm tool shouldn't be drilled with a 1.0 mm bit by default")
+	}
+	if mappings := drl.MapTools(&ToolLibrary{Tolerance: 0.1, Bits: []DrillBit{{Diameter: 1.0}}}); mappings[0].Bit == nil {
+		t.Errorf("0.9 mm tool should be drilled with a 1.0 mm bit with a 0.1 mm tolerance")
+	}
+}
+
+func checkRoute(t *testing.T, steps []*Step, want []*Step) {
+	t.Helper()
+
+	if len(steps) != len(want) {
+		t.Fatalf("Got %d steps, want %d", len(steps), len(want))
+	}
+	for i, step := range steps {
+		if step.typ != want[i].typ {
+			t.Errorf("Step %d is %s, want %s", i, step.typ, want[i].typ)
+			continue
+		}
+		checkPoint(t, "Step "+step.typ, step.x, step.y, want[i].x, want[i].y)
+		if step.typ == "A" || step.typ == "C" {
+			checkPoint(t, "Arc centre", step.cx, step.cy, want[i].cx, want[i].cy)
+		}
+	}
+}
+
+func TestMapToolsMilledHole(t *testing.T) {
+	drl := parseTestDrl(t, "M48\nMETRIC\nT1C3.0\n%\nT1\nX10.0Y10.0\nM30\n")
+	library := readTestLibrary(t, `{"bits": [
+		{"diameter": 1.0},
+		{"diameter": 1.0, "endMill": true},
+		{"diameter": 2.0, "endMill": true},
+		{"diameter": 3.0, "endMill": true}
+	]}`)
+
+	mappings := drl.MapTools(library)
+	if len(mappings) != 1 || !mappings[0].Milled || mappings[0].Bit.Diameter != 2.0 {
+		t.Fatalf("Got %v, want T1 milled with the 2.0 mm end mill", mappings)
+	}
+	if drl.Tools[1].size != 2.0 {
+		t.Errorf("T1 is %f mm, want 2.0", drl.Tools[1].size)
+	}
+
+	// The edge of the end mill runs round the edge of the hole, as a full circle
+	checkRoute(t, drl.Steps, []*Step{
+		{typ: "T"},
+		{typ: "G", x: 10.5, y: 10.0},
+		{typ: "P", x: 10.5, y: 10.0},
+		{typ: "A", x: 10.5, y: 10.0, cx: 10.0, cy: 10.0},
+		{typ: "U", x: 10.5, y: 10.0},
+	})
+
+	bounds := NewDrillBounds()
+	if err := drl.GetBounds(bounds); err != nil {
+		t.Fatalf("GetBounds failed: %v", err)
+	}
+	xMin, xMax, yMin, yMax := bounds.Get()
+	checkPoint(t, "Bottom left", xMin, yMin, 8.5, 8.5)
+	checkPoint(t, "Top right", xMax, yMax, 11.5, 11.5)
+}
+
+func TestMapToolsMilledSlot(t *testing.T) {
+	drl := parseTestDrl(t, "M48\nMETRIC\nT1C3.0\n%\nT1\nX0Y0G85X10.0Y0\nM30\n")
+	library := readTestLibrary(t, `{"bits": [{"diameter": 1.0}, {"diameter": 2.0, "endMill": true}]}`)
+
+	mappings := drl.MapTools(library)
+	if len(mappings) != 1 || !mappings[0].Milled {
+		t.Fatalf("Got %v, want T1 milled", mappings)
+	}
+
+	// Counterclockwise round the outline of the slot, starting at the near end on its right side
+	checkRoute(t, drl.Steps, []*Step{
+		{typ: "T"},
+		{typ: "G", x: 0.0, y: -0.5},
+		{typ: "P", x: 0.0, y: -0.5},
+		{typ: "L", x: 10.0, y: -0.5},
+		{typ: "A", x: 10.0, y: 0.5, cx: 10.0, cy: 0.0},
+		{typ: "L", x: 0.0, y: 0.5},
+		{typ: "A", x: 0.0, y: -0.5, cx: 0.0, cy: 0.0},
+		{typ: "U", x: 0.0, y: -0.5},
+	})
+}
+
+func TestMapToolsLeavesRoutes(t *testing.T) {
+	drl := parseTestDrl(t, `M48
+METRIC
+T1C3.0
+%
+T1
+G00X0Y0
+M15
+G01X10.0Y0
+M16
+M30
+`)
+	library := readTestLibrary(t, `{"bits": [{"diameter": 2.0, "endMill": true}]}`)
+
+	drl.MapTools(library)
+
+	// The route already says where the tool goes, so only the plain holes would be turned into routes
+	checkRoute(t, drl.Steps, []*Step{
+		{typ: "T"},
+		{typ: "G", x: 0.0, y: 0.0},
+		{typ: "P", x: 0.0, y: 0.0},
+		{typ: "L", x: 10.0, y: 0.0},
+		{typ: "U", x: 10.0, y: 0.0},
+	})
+	if size := drl.Tools[1].size; math.Abs(size-2.0) > drillTestTolerance {
+		t.Errorf("T1 is %f mm, want 2.0", size)
+	}
+}
@@ -23,3 +23,13 @@ func (env *ExpressionEnvironment) getVariableValue(variable int) float64 {
 func (env *ExpressionEnvironment) setVariableValue(variable int, value float64) {
 	env.variables[variable] = value
 }
+
+// copy returns a new environment with the same variable values, so that a macro can be executed without changing this one
+func (env *ExpressionEnvironment) copy() *ExpressionEnvironment {
+	newEnv := NewExpressionEnvironment()
+	for key, value := range env.variables {
+		newEnv.setVariableValue(key, value)
+	}
+
+	return newEnv
+}
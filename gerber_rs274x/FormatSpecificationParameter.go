@@ -3,11 +3,10 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type FormatSpecificationParameter struct {
+	dataBlockPosition
 	paramCode          ParameterCode
 	zeroOmissionMode   ZeroOmissionMode
 	coordinateNotation CoordinateNotation
@@ -17,11 +16,6 @@ type FormatSpecificationParameter struct {
 	yNumDecimals       int
 }
 
-func (formatSpecification *FormatSpecificationParameter) ProcessDataBlockToolpath(camo *CamOutput, gfxState *GraphicsState) error {
-	formatSpecification.ProcessDataBlockSurface(nil, gfxState)
-	return nil
-}
-
 func (formatSpecification *FormatSpecificationParameter) DataBlockPlaceholder() {
 
 }
@@ -38,16 +32,8 @@ func (formatSpecification *FormatSpecificationParameter) ProcessDataBlockBoundsC
 	return nil
 }
 
-func (formatSpecification *FormatSpecificationParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	if gfxState.coordinateNotationSet {
-		return fmt.Errorf("Tried to process illegal 2nd format specification parameter")
-	}
-
-	gfxState.coordinateNotation = formatSpecification.coordinateNotation
-	gfxState.coordinateNotationSet = true
-	gfxState.filePrecision = 1.0 / math.Pow10(formatSpecification.xNumDecimals)
-
-	return nil
+func (formatSpecification *FormatSpecificationParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return formatSpecification.ProcessDataBlockBoundsCheck(nil, gfxState)
 }
 
 func (fsParam *FormatSpecificationParameter) String() string {
@@ -0,0 +1,104 @@
+package gerber_rs274x
+
+// GerberImage is the interpreted form of a parsed gerber file: the graphics objects it creates, and the attributes
+// attached to the file, its apertures and its objects.  It sits between the parser and the output backends, so that a
+// new output format only has to know how to draw graphics objects, not how to interpret a gerber file
+type GerberImage struct {
+	units              Units
+	imagePolarity      Polarity
+	bounds             *ImageBounds
+	complete           bool
+	fileAttributes     AttributeDictionary
+	apertureAttributes map[int]AttributeDictionary
+	graphicsObjects    []*GraphicsObject
+}
+
+// InterpretGerberFile works through a parsed file, keeping track of the attribute dictionaries and recording every
+// graphics object the file creates.  The image parameters apply to the whole image, wherever they appear in the file,
+// so they are collected by a bounds pass first, and the interpret pass starts out with them already in place
+func InterpretGerberFile(parsedFile []DataBlock) (*GerberImage, error) {
+	bounds, gfxStateBounds, err := processBounds(parsedFile)
+	if err != nil {
+		return nil, err
+	}
+
+	gfxState := newGraphicsState()
+	gfxState.imageParams = gfxStateBounds.imageParams
+	gfxState.currentLevelPolarity = gfxState.imageParams.effectivePolarity(DARK_POLARITY)
+
+	err = processDataBlocks(parsedFile, gfxState, func(dataBlock DataBlock) error {
+		return dataBlock.ProcessDataBlockInterpret(gfxState)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	image := new(GerberImage)
+	image.units = gfxState.units
+	image.imagePolarity = gfxState.imageParams.polarity
+	image.bounds = bounds.toMillimetres(gfxState.units)
+	image.complete = gfxState.fileComplete
+	image.fileAttributes = gfxState.fileAttributes
+	image.apertureAttributes = gfxState.definedApertureAttributes
+	image.graphicsObjects = gfxState.graphicsObjects
+
+	return image, nil
+}
+
+// GetUnits returns the units the file was written in.  The image's coordinates are always in millimetres, whatever the
+// file units were
+func (image *GerberImage) GetUnits() Units {
+	return image.units
+}
+
+// GetImagePolarity returns the polarity set by the IP parameter.  The polarity of every object already takes it into
+// account, but a negative image also starts out completely dark, which backends have to draw themselves
+func (image *GerberImage) GetImagePolarity() Polarity {
+	return image.imagePolarity
+}
+
+// GetBounds returns the extent of the image, in millimetres
+func (image *GerberImage) GetBounds() (xMin float64, xMax float64, yMin float64, yMax float64) {
+	return image.bounds.Get()
+}
+
+// IsComplete reports whether the file ended with an end of file code (M02)
+func (image *GerberImage) IsComplete() bool {
+	return image.complete
+}
+
+func (image *GerberImage) GetFileAttributes() AttributeDictionary {
+	return image.fileAttributes
+}
+
+// GetFileFunction returns the values of the .FileFunction file attribute (eg. Copper,L1,Top), or nil if the file doesn't have one
+func (image *GerberImage) GetFileFunction() []string {
+	return image.fileAttributes[".FileFunction"]
+}
+
+// GetApertureAttributes returns the attributes that were attached to an aperture when it was defined
+func (image *GerberImage) GetApertureAttributes(apertureNumber int) (attributes AttributeDictionary, found bool) {
+	attributes, found = image.apertureAttributes[apertureNumber]
+	return attributes, found
+}
+
+// GetObjects returns every graphics object in the file, in the order they were created
+func (image *GerberImage) GetObjects() []*GraphicsObject {
+	return image.graphicsObjects
+}
+
+// Render draws every graphics object in the image with the supplied backend, in the order they were created.  Objects
+// have to be drawn in order, since clear polarity objects only erase what has already been drawn
+func (image *GerberImage) Render(backend ImageBackend) error {
+	if err := backend.BeginImage(image); err != nil {
+		return err
+	}
+
+	for _, graphicsObject := range image.graphicsObjects {
+		if err := backend.DrawObject(graphicsObject); err != nil {
+			return err
+		}
+	}
+
+	return backend.EndImage()
+}
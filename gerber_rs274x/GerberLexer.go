@@ -0,0 +1,150 @@
+package gerber_rs274x
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// This caps the size of a single block, so that a corrupt file (for example, one with a missing "*" or "%" terminator)
+// can't make the lexer buffer the entire rest of the file.  Real blocks are tiny, the biggest ones being aperture macros
+// with long outline primitives, so this is set very generously
+const MAX_BLOCK_LENGTH int = 1 << 20
+
+// GerberLexer splits a gerber file into parameter blocks (delimited by "%" characters) and data blocks (terminated by a "*" character).
+// It reads the input incrementally, so only the block currently being tokenized is ever held in memory
+type GerberLexer struct {
+	reader *bufio.Reader
+	buffer []byte
+
+	// Position of the next character to be read, and of the first character of the block currently being read
+	nextPosition  SourcePosition
+	blockPosition SourcePosition
+}
+
+type LexedBlock struct {
+	text        string
+	isParameter bool
+	position    SourcePosition
+}
+
+func NewGerberLexer(in io.Reader) *GerberLexer {
+	lexer := new(GerberLexer)
+	lexer.reader = bufio.NewReader(in)
+	lexer.buffer = make([]byte, 0, 256) // Start with an initial capacity of 256 bytes, it will grow as necessary
+	lexer.nextPosition = SourcePosition{Line: 1, Column: 1}
+
+	return lexer
+}
+
+// NextBlock returns the next block in the file, or io.EOF once the whole file has been consumed
+func (lexer *GerberLexer) NextBlock() (*LexedBlock, error) {
+	lexer.buffer = lexer.buffer[:0]
+
+	for {
+		charPosition := lexer.nextPosition
+		char, err := lexer.readByte()
+		if err == io.EOF {
+			// Running out of input in between blocks is the normal way for a file to end.  Anything left over in the buffer
+			// is an unterminated data block
+			if len(lexer.buffer) > 0 {
+				return nil, lexer.newLexerError(UNTERMINATED_BLOCK_ERROR, "Unexpected end of file inside data block")
+			}
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, fmt.Errorf("Error encountered while reading file: %v", err)
+		}
+
+		switch char {
+		case '\r', '\n':
+			// Line breaks carry no meaning in a gerber file, they can even appear in the middle of a block
+
+		case '%':
+			// A parameter block can't start in the middle of a data block
+			if len(lexer.buffer) > 0 {
+				return nil, lexer.newLexerError(UNTERMINATED_BLOCK_ERROR, "Unterminated data block before start of parameter block")
+			}
+			lexer.blockPosition = charPosition
+			return lexer.readParameterBlock()
+
+		case '*':
+			// Skip over empty data blocks, there's nothing to parse in them
+			if len(lexer.buffer) > 0 {
+				return &LexedBlock{string(lexer.buffer), false, lexer.blockPosition}, nil
+			}
+
+		default:
+			if len(lexer.buffer) == 0 {
+				// This is the first character of a new data block, so remember where the block starts
+				lexer.blockPosition = charPosition
+			}
+			if err := lexer.appendToBlock(char); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (lexer *GerberLexer) readParameterBlock() (*LexedBlock, error) {
+	for {
+		char, err := lexer.readByte()
+		if err == io.EOF {
+			return nil, lexer.newLexerError(UNTERMINATED_BLOCK_ERROR, "Unexpected end of file inside parameter block")
+		} else if err != nil {
+			return nil, fmt.Errorf("Error encountered while reading file: %v", err)
+		}
+
+		switch char {
+		case '\r', '\n':
+			// Parameter blocks (especially aperture macros) are often split across several lines
+
+		case '%':
+			// Parameters can have multiple embedded data blocks, so they contain multiple "*" characters.  We keep the embedded
+			// ones, but strip the "*" that ends the final data block, since the parameter parsers don't expect it
+			if len(lexer.buffer) > 0 && lexer.buffer[len(lexer.buffer)-1] == '*' {
+				lexer.buffer = lexer.buffer[:len(lexer.buffer)-1]
+			}
+			return &LexedBlock{string(lexer.buffer), true, lexer.blockPosition}, nil
+
+		default:
+			if err := lexer.appendToBlock(char); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (lexer *GerberLexer) appendToBlock(char byte) error {
+	if len(lexer.buffer) >= MAX_BLOCK_LENGTH {
+		return lexer.newLexerError(BLOCK_TOO_LONG_ERROR, "Block exceeds maximum length of %d bytes", MAX_BLOCK_LENGTH)
+	}
+	lexer.buffer = append(lexer.buffer, char)
+
+	return nil
+}
+
+func (lexer *GerberLexer) readByte() (byte, error) {
+	char, err := lexer.reader.ReadByte()
+	if err != nil {
+		return char, err
+	}
+
+	// Keep track of where we are in the file, so blocks can be tagged with their source position
+	lexer.nextPosition.Offset++
+	if char == '\n' {
+		lexer.nextPosition.Line++
+		lexer.nextPosition.Column = 1
+	} else {
+		lexer.nextPosition.Column++
+	}
+
+	return char, nil
+}
+
+func (lexer *GerberLexer) newLexerError(code ParseErrorCode, format string, args ...interface{}) *ParseError {
+	parseError := newParseError(code, format, args...)
+	parseError.Position = lexer.blockPosition
+	parseError.Raw = string(lexer.buffer)
+
+	return parseError
+}
@@ -0,0 +1,107 @@
+package gerber_rs274x
+
+import (
+	"io"
+)
+
+// GerberParser parses a gerber file one data block at a time, so that callers that don't need the whole file in memory
+// (for example, when processing very large panelized layers) can handle each data block as soon as it is parsed
+type GerberParser struct {
+	lexer       *GerberLexer
+	parseEnv    *ParseEnvironment
+	options     ParseOptions
+	parseErrors ParseErrorList
+
+	// Set once an M02 has been parsed, or once the missing M02 has been reported
+	endOfFileSeen bool
+}
+
+// NewGerberParser creates a parser that uses lenient parsing
+func NewGerberParser(in io.Reader) *GerberParser {
+	return NewGerberParserWithOptions(in, ParseOptions{})
+}
+
+func NewGerberParserWithOptions(in io.Reader, options ParseOptions) *GerberParser {
+	parser := new(GerberParser)
+	parser.lexer = NewGerberLexer(in)
+	parser.parseEnv = newParseEnv(options)
+	parser.options = options
+
+	return parser
+}
+
+// NextDataBlock returns the next data block in the file, or io.EOF once the whole file has been parsed.
+// In lenient mode, blocks that fail to parse are skipped, and the problem is recorded in the parser's error list.  Only errors
+// that prevent parsing from continuing (such as I/O errors or an unterminated block) are returned directly.  In strict mode,
+// the first problem found is also returned directly, and parsing should not be continued after that
+func (parser *GerberParser) NextDataBlock() (DataBlock, error) {
+	for {
+		block, err := parser.lexer.NextBlock()
+		if err == io.EOF {
+			if !parser.endOfFileSeen {
+				// Every file is supposed to end with an M02, but plenty of older files just stop
+				parser.endOfFileSeen = true
+				endOfInput := &LexedBlock{position: parser.lexer.nextPosition}
+				err := parser.parseEnv.specViolation(MISSING_END_OF_FILE_ERROR, "File ended without an M02 end of file code")
+				if err := parser.recordProblems(err, MISSING_END_OF_FILE_ERROR, endOfInput); err != nil {
+					return nil, err
+				}
+			}
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, err
+		}
+
+		var dataBlock DataBlock
+		var defaultCode ParseErrorCode
+		if block.isParameter {
+			// Parsing Parameter
+			dataBlock, err = parseParameter(block.text, parser.parseEnv)
+			defaultCode = INVALID_PARAMETER_ERROR
+		} else {
+			// Parsing non-parameter data block
+			dataBlock, err = parseDataBlock(block.text, parser.parseEnv)
+			defaultCode = INVALID_DATA_BLOCK_ERROR
+		}
+
+		if err := parser.recordProblems(err, defaultCode, block); err != nil {
+			return nil, err
+		} else if dataBlock == nil {
+			// The block couldn't be parsed, but we're being lenient, so move on to the next one
+			continue
+		}
+
+		if stateChange, isStateChange := dataBlock.(*GraphicsStateChange); isStateChange && stateChange.fnCode == END_OF_FILE {
+			parser.endOfFileSeen = true
+		}
+
+		dataBlock.setPosition(block.position)
+		return dataBlock, nil
+	}
+}
+
+// recordProblems adds the error from parsing a block (if there was one), along with any warnings raised while parsing
+// it, to the parser's error list.  In strict mode, the located error is handed back so that parsing stops
+func (parser *GerberParser) recordProblems(err error, defaultCode ParseErrorCode, block *LexedBlock) error {
+	for _, warning := range parser.parseEnv.warnings {
+		parser.parseErrors = append(parser.parseErrors, locateParseError(warning, defaultCode, block))
+	}
+	parser.parseEnv.warnings = parser.parseEnv.warnings[:0]
+
+	if err == nil {
+		return nil
+	}
+
+	parseError := locateParseError(err, defaultCode, block)
+	parser.parseErrors = append(parser.parseErrors, parseError)
+	if parser.options.Mode == STRICT_PARSE_MODE {
+		return parseError
+	}
+
+	return nil
+}
+
+// Errors returns the problems found in the blocks parsed so far
+func (parser *GerberParser) Errors() ParseErrorList {
+	return parser.parseErrors
+}
@@ -1,17 +1,13 @@
 package gerber_rs274x
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"math"
 	"regexp"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 var coordDataBlockRegex *regexp.Regexp
-var parameterOrDataBlockRegex *regexp.Regexp
 var dataBlockRegex *regexp.Regexp
 var dCodeDataBlockRegex *regexp.Regexp
 var coordinateDataBlockRegex *regexp.Regexp
@@ -19,10 +15,7 @@ var fsParameterRegex *regexp.Regexp
 var srParameterRegex *regexp.Regexp
 var adParameterRegex *regexp.Regexp
 var amVariableDefinitionRegex *regexp.Regexp
-var taParameterRegex *regexp.Regexp
-var tdParameterRegex *regexp.Regexp
-var tfParameterRegex *regexp.Regexp
-var toParameterRegex *regexp.Regexp
+var imageAxisParameterRegex *regexp.Regexp
 
 const ONE_HALF_PI = (math.Pi / 2.0)
 const THREE_HALVES_PI = ((math.Pi * 3.0) / 2.0)
@@ -48,12 +41,12 @@ type ParseEnvironment struct {
 	coordFormat      CoordinateFormat
 	unitsSet         bool
 	aperturesDefined map[int]bool
-}
 
-type ScalingParms struct {
-	scaleFactor float64
-	xOffset     float64
-	yOffset     float64
+	// The most recent D01, D02 or D03 operation code, which lenient mode reuses for coordinate data without a D code
+	modalDCode int
+
+	options  ParseOptions
+	warnings []*ParseError
 }
 
 func init() {
@@ -62,12 +55,6 @@ func init() {
 
 	coordDataBlockRegex = regexp.MustCompile(`(?:X(?P<xCoord>-?[[:digit:]]*))?(?:Y(?P<yCoord>-?[[:digit:]]*))?(?:I(?P<iOffset>-?[[:digit:]]*))?(?:J(?P<jOffset>-?[[:digit:]]*))?`)
 
-	// Regular expression that matches either a parameter block in between "%" characters, or a data block ended by a "*" character
-	// NOTE: The parameter matching part is tricky, since parameters can have multiple embedded data blocks (meaning multiple embedded "*" characters)
-	// but we still want to match the optional "*" character at the end of the parameter block but not capture it, so we use a non-greedy "*" matching clause
-	// inside the capturing expression, but a greedy "*" matching clause at the end of the parameter matching section
-	parameterOrDataBlockRegex = regexp.MustCompile("(?:%(?P<paramBlock>(?:[[:alnum:] -\\$&-\\)\\+-/:<-@[-`{-~]*\\**?)*)\\*?%)|(?:(?P<dataBlock>[[:alnum:] -\\$&-\\)\\+-/:<-@[-`{-~]*)\\*)")
-
 	dataBlockRegex = regexp.MustCompile(`(?:(?P<fnLetter>G|M)(?P<fnCode>[[:digit:]]{1,2}))?(?P<restOfBlock>[[:alnum:][:punct:] ]*)`)
 
 	dCodeDataBlockRegex = regexp.MustCompile(`(?P<restOfBlock>[XYIJ\-[:digit:]]*)(?:D(?P<dCode>[[:digit:]]{1,2}))?`)
@@ -81,61 +68,33 @@ func init() {
 	adParameterRegex = regexp.MustCompile(`D(?P<dCode>[[:digit:]]*)(?P<apertureType>[[:alnum:]_\+\-/\!\?<>"'\(\){}\.\\\|\&@# ]+),?(?P<modifiers>[[:digit:]\.X\-]*)`)
 
 	amVariableDefinitionRegex = regexp.MustCompile(`\$(?P<varNum>[[:digit:]]+)=(?P<varExp>[[:digit:]$.+-x/]+)`)
-	// %TA.AperFunction,SMDPad,CuDef*%
-	// %TA.AperFunction,ViaPad*%
-	// %TD*%
-	// %TF.CreationDate,2021-02-25T09:53:50-07:00*%
-	// %TF.ProjectId,amp,616d702e-6b69-4636-9164-5f7063625858,rev?*%
-	// %TO.P,R99,1*%
-	// %TO.N,Net-(Q2-Pad1)*%
-	taParameterRegex = regexp.MustCompile(`.([^,]*),(.*)`)
-	tdParameterRegex = regexp.MustCompile(`.([^,]*),(.*)`)
-	tfParameterRegex = regexp.MustCompile(`.([^,]*),(.*)`)
-	toParameterRegex = regexp.MustCompile(`.([^,]*),(.*)`)
-}
 
-func ParseGerberFile(in io.Reader) (parsedFile []DataBlock, err error) {
-	scanner := bufio.NewScanner(in)
-	scanner.Split(bufio.ScanLines)
-	fileString := ""
-	for scanner.Scan() {
-		fileString += scanner.Text()
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("Error encountered while reading file: %v\n", err)
-	}
+	imageAxisParameterRegex = regexp.MustCompile(`^(?:A(?P<aValue>[+-]?[[:digit:]]*\.?[[:digit:]]*))?(?:B(?P<bValue>[+-]?[[:digit:]]*\.?[[:digit:]]*))?$`)
+}
 
-	results := parameterOrDataBlockRegex.FindAllStringSubmatch(fileString, -1)
+// ParseGerberFile parses an entire gerber file in lenient mode.  Blocks that can't be parsed are left out of the parsed
+// file, and are reported in the returned list of parse errors along with any warnings.  The error return is only set if
+// parsing had to be abandoned altogether
+func ParseGerberFile(in io.Reader) (parsedFile []DataBlock, parseErrors ParseErrorList, err error) {
+	return ParseGerberFileWithOptions(in, ParseOptions{})
+}
 
+// ParseGerberFileWithOptions parses an entire gerber file using the supplied options.  In strict mode, the first spec
+// violation abandons parsing and is returned as the error
+func ParseGerberFileWithOptions(in io.Reader, options ParseOptions) (parsedFile []DataBlock, parseErrors ParseErrorList, err error) {
 	// Set up the variables we'll need for parsing
 	// We'll start with a default size of 100 for now
 	// The slice will grow as necessary during parsing
-	parseEnv := newParseEnv()
+	parser := NewGerberParserWithOptions(in, options)
 	parsedFile = make([]DataBlock, 0, 100)
 
-	for index, submatch := range results {
-		if len(submatch) != 3 {
-			return nil, fmt.Errorf("Error (token %d): Parse error on command %v\n", index, submatch)
-		}
-
-		if len(submatch[1]) > 0 {
-			// Parsing Parameter
-			// fmt.Printf("Token %d, Parsed parameter: %s\n", index, submatch[1])
-			if parameter, err := parseParameter(submatch[1], parseEnv); err != nil {
-				fmt.Printf("Parse error for parameter %s: %s\n", submatch[1], err.Error())
-			} else {
-				parsedFile = append(parsedFile, parameter)
-			}
-		} else if len(submatch[2]) > 0 {
-			// Parsing non-parameter data block
-			if dataBlock, err := parseDataBlock(submatch[2], parseEnv); err != nil {
-				fmt.Printf("Parse Error for block %s: %s\n", submatch[2], err.Error())
-			} else {
-				parsedFile = append(parsedFile, dataBlock)
-			}
+	for {
+		if dataBlock, err := parser.NextDataBlock(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, parser.Errors(), err
 		} else {
-			return nil, fmt.Errorf("Error (token %d): Not parameter or data block: %v\n", index, submatch)
+			parsedFile = append(parsedFile, dataBlock)
 		}
 	}
 
@@ -143,120 +102,60 @@ func ParseGerberFile(in io.Reader) (parsedFile []DataBlock, err error) {
 		fmt.Printf("Parsed data block %3d: %v\n", index, dataBlock)
 	}*/
 
-	return parsedFile, nil
+	return parsedFile, parser.Errors(), nil
 }
 
+// GenerateToolpath writes the toolpath for a parsed file.  The file is interpreted first, and the resulting image is
+// drawn with the toolpath generator, so the caller's translate/scale function is given image coordinates in millimetres.
+// The image parameters (polarity, scale factor, offset, rotation and mirroring) apply to the toolpath just as they do to
+// the rendered image, including to the sizes of the apertures
 func GenerateToolpath(camo *CamOutput, parsedFile []DataBlock) error {
-
-	width := 800
-	height := 800
-
-	gfxStateBounds := newGraphicsState(nil, 0, 0)
-	bounds := newImageBounds()
-
-	for _, dataBlock := range parsedFile {
-		if err := dataBlock.ProcessDataBlockBoundsCheck(bounds, gfxStateBounds); err != nil {
-			return err
-		}
+	if camo.mode == RASTER_TOOLPATH_MODE {
+		return generateRaster(camo, parsedFile)
 	}
 
-	// Set up the graphics state for the actual drawing
-	gfxState := newGraphicsState(bounds, width, height)
-	fmt.Fprintf(camo.wrt, "; My CAM\n")
-	fmt.Fprintf(camo.wrt, "G90G40G17G21\n")
-	fmt.Fprintf(camo.wrt, "F300\n")
-
-	fmt.Fprintf(camo.wrt, "; X Bounds: (%f %f) Y Bounds: (%f %f)\n", bounds.xMin, bounds.xMax, bounds.yMin, bounds.yMax)
-	fmt.Printf("X Bounds: (%f %f) Y Bounds: (%f %f)\n", bounds.xMin, bounds.xMax, bounds.yMin, bounds.yMax)
-
-	/*camo.translateScale = func(x, y float64) (x1, y1 float64) {
-		return x - bounds.xMin, y - bounds.yMin
-	}*/
-
-	for i, dataBlock := range parsedFile {
-		if i == 61 {
-			fmt.Println(i)
-		}
-		if err := dataBlock.ProcessDataBlockToolpath(camo, gfxState); err != nil {
-			return err
-		}
+	image, err := InterpretGerberFile(parsedFile)
+	if err != nil {
+		return err
 	}
-	return nil
+
+	return image.Render(camo)
 }
 
+// GenerateBounds extends bounds to cover a parsed file.  Bounds are in image coordinates (millimetres, with the image
+// parameters applied), the same coordinates the toolpath generators give to their translate/scale functions, so the
+// bounds of several layers (and of a drill file) can be combined
 func GenerateBounds(parsedFile []DataBlock, bounds *ImageBounds) (err error) {
-	gfxStateBounds := newGraphicsState(nil, 0, 0)
-	for _, dataBlock := range parsedFile {
-		if err = dataBlock.ProcessDataBlockBoundsCheck(bounds, gfxStateBounds); err != nil {
-			return err
-		}
+	fileBounds, gfxState, err := processBounds(parsedFile)
+	if err != nil {
+		return err
+	}
+	if fileBounds.boundsSet {
+		bounds.updateBounds(fileBounds.toMillimetres(gfxState.units).Get())
 	}
 	fmt.Printf("X Bounds: (%f %f) Y Bounds: (%f %f)\n", bounds.xMin, bounds.xMax, bounds.yMin, bounds.yMax)
 	return err
 }
 
-func GenerateSurface(outFileName string, parsedFile []DataBlock) error {
-
-	width := 800
-	height := 800
-
-	// First, need to do a full render of the file, just keeping track of the bounds
-	// of the generated image, so we can do the proper scaling when we render it for real
-	gfxStateBounds := newGraphicsState(nil, 0, 0)
+// processBounds runs the bounds pass over a parsed file.  The returned bounds have the file's image parameters applied,
+// and the returned graphics state holds those image parameters, so the interpret pass can apply the same transformation
+func processBounds(parsedFile []DataBlock) (*ImageBounds, *GraphicsState, error) {
+	gfxStateBounds := newGraphicsState()
 	bounds := newImageBounds()
 
-	for _, dataBlock := range parsedFile {
-		if err := dataBlock.ProcessDataBlockBoundsCheck(bounds, gfxStateBounds); err != nil {
-			return err
-		}
-	}
-
-	fmt.Printf("X Bounds: (%f %f) Y Bounds: (%f %f)\n", bounds.xMin, bounds.xMax, bounds.yMin, bounds.yMax)
-
-	// Set up the graphics state for the actual drawing
-	gfxState := newGraphicsState(bounds, width, height)
-
-	// Construct the surface we're drawing to
-	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, width, height)
-	surface.SetAntialias(cairo.ANTIALIAS_NONE)
-
-	// This is important for regions with cut-ins.  If we leave the fill rule the default (winding),
-	// cut-ins don't render correctly
-	surface.SetFillRule(cairo.FILL_RULE_EVEN_ODD)
-	// Invert the Y-axis.  This is to correct for the difference in coordinate frames between the gerber file and cairo
-	surface.Scale(1.0, -1.0)
-	surface.Translate(0.0, float64(-height))
-	// Apply the x and y offsets as translations to the surface
-	surface.Translate(gfxState.xOffset, gfxState.yOffset)
-
-	// Push the surface state onto the stack before we scale it, so we can selectively remove the scaling later
-	// (used for drawing apertures onto the surface, because apertures are pre-rendered to their own surfaces
-	// and are already scaled, so we don't want to apply scaling twice)
-	surface.Save()
-	surface.Scale(gfxState.scaleFactor, gfxState.scaleFactor)
-
-	for _, dataBlock := range parsedFile {
-		if err := dataBlock.ProcessDataBlockSurface(surface, gfxState); err != nil {
-			gfxState.releaseRenderedSurfaces()
-			surface.Finish()
-			return err
-		}
-	}
-	gfxState.releaseRenderedSurfaces()
-
-	surface.WriteToPNG(outFileName)
-	surface.Finish()
-
-	// Make sure that the entire file was rendered
-	if !gfxState.fileComplete {
-		return fmt.Errorf("Render of file completed without reaching end of file code (M02)")
+	err := processDataBlocks(parsedFile, gfxStateBounds, func(dataBlock DataBlock) error {
+		return dataBlock.ProcessDataBlockBoundsCheck(bounds, gfxStateBounds)
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
+	return gfxStateBounds.imageParams.transformBounds(bounds), gfxStateBounds, nil
 }
 
-func newParseEnv() *ParseEnvironment {
+func newParseEnv(options ParseOptions) *ParseEnvironment {
 	parseEnv := new(ParseEnvironment)
+	parseEnv.options = options
 	parseEnv.aperturesDefined = make(map[int]bool, 10) // We'll start with an initial capacity of 10, it will grow as necessary
 
 	return parseEnv
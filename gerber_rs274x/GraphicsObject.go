@@ -0,0 +1,290 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+)
+
+type GraphicsObjectType int
+
+const (
+	FLASH_OBJECT GraphicsObjectType = iota
+	DRAW_OBJECT
+	ARC_OBJECT
+	REGION_OBJECT
+)
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+// ContourSegment is one segment of a region contour, running from the end of the previous segment (or the start of the
+// contour) to End.  Arc segments turn about Center by SweepAngle radians, which is positive for counterclockwise arcs
+type ContourSegment struct {
+	End        Point
+	IsArc      bool
+	Center     Point
+	SweepAngle float64
+}
+
+// GraphicsObject is a single flash, draw, arc or region created by a gerber file, resolved so that it can be output without
+// any further reference to the graphics state: coordinates are absolute and in millimetres, with the image parameters
+// applied, and the aperture comes with everything needed to draw it.  The attributes that were attached to the object
+// when it was created are kept with it
+type GraphicsObject struct {
+	objectType GraphicsObjectType
+	polarity   Polarity
+	start      Point
+	end        Point
+
+	// Arcs only
+	center     Point
+	sweepAngle float64
+
+	// Regions only
+	contour []ContourSegment
+
+	// Flashes, draws and arcs only.  The aperture is defined in file units and coordinates, so the aperture transform
+	// maps its shape into the image (it never includes a translation).  Macro apertures also need their macro definition
+	apertureNumber    int
+	aperture          Aperture
+	apertureMacro     []ApertureMacroDataBlock
+	apertureTransform AffineTransform
+
+	apertureAttributes AttributeDictionary
+	objectAttributes   AttributeDictionary
+}
+
+func (gfxState *GraphicsState) recordGraphicsObject(opCode OperationCode, move *InterpolationMove) {
+	imageTransform := gfxState.imageTransform()
+	current := imageTransform.Apply(Point{gfxState.currentX, gfxState.currentY})
+	next := imageTransform.Apply(Point{move.newX, move.newY})
+
+	isArc := false
+	var center Point
+	var sweepAngle float64
+	if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE || gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE {
+		isArc = true
+		center = imageTransform.Apply(Point{move.centerX, move.centerY})
+		sweepAngle = gfxState.arcSweepAngle(move)
+
+		// Mirroring the image reverses the direction of every arc
+		if imageTransform.IsMirrored() {
+			sweepAngle = -sweepAngle
+		}
+	}
+
+	if gfxState.regionModeOn {
+		switch opCode {
+		case INTERPOLATE_OPERATION:
+			// The first segment of a contour creates the region object, the rest just extend it
+			if gfxState.currentRegion == nil {
+				// Regions have no aperture, so they pick up the current aperture attributes directly
+				gfxState.currentRegion = gfxState.newGraphicsObject(REGION_OBJECT, gfxState.apertureAttributes.copy())
+				gfxState.currentRegion.start = current
+			}
+			gfxState.currentRegion.end = next
+			gfxState.currentRegion.contour = append(gfxState.currentRegion.contour, ContourSegment{next, isArc, center, sweepAngle})
+
+		case MOVE_OPERATION:
+			// A move inside region mode ends the current contour, so the next segment starts a new region
+			gfxState.currentRegion = nil
+		}
+
+		return
+	}
+
+	var graphicsObject *GraphicsObject
+
+	switch opCode {
+	case FLASH_OPERATION:
+		graphicsObject = gfxState.newGraphicsObject(FLASH_OBJECT, gfxState.definedApertureAttributes[gfxState.currentAperture])
+		graphicsObject.start = next
+
+	case INTERPOLATE_OPERATION:
+		if isArc {
+			graphicsObject = gfxState.newGraphicsObject(ARC_OBJECT, gfxState.definedApertureAttributes[gfxState.currentAperture])
+			graphicsObject.center = center
+			graphicsObject.sweepAngle = sweepAngle
+		} else {
+			graphicsObject = gfxState.newGraphicsObject(DRAW_OBJECT, gfxState.definedApertureAttributes[gfxState.currentAperture])
+		}
+		graphicsObject.start = current
+
+	default:
+		// Moves don't create anything
+		return
+	}
+
+	graphicsObject.end = next
+	graphicsObject.apertureNumber = gfxState.currentAperture
+	graphicsObject.apertureTransform = imageTransform.Linear()
+	if aperture, found := gfxState.apertures[gfxState.currentAperture]; found {
+		graphicsObject.aperture = aperture
+		if macroAperture, isMacro := aperture.(*MacroAperture); isMacro {
+			graphicsObject.apertureMacro = gfxState.apertureMacros[macroAperture.macroName]
+		}
+	}
+}
+
+// arcSweepAngle works out how far an arc turns, from the start and end angles of the move.  In multi quadrant mode, an
+// arc that ends where it starts is a full circle, while in single quadrant mode it has no length at all
+func (gfxState *GraphicsState) arcSweepAngle(move *InterpolationMove) float64 {
+	sweepAngle := move.endAngle - move.startAngle
+	if math.Abs(sweepAngle) < 1e-9 {
+		if gfxState.currentQuadrantMode == SINGLE_QUADRANT_MODE {
+			return 0.0
+		}
+		sweepAngle = 0.0
+	}
+
+	if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE {
+		if sweepAngle <= 0.0 {
+			sweepAngle += TWO_PI
+		}
+	} else {
+		if sweepAngle >= 0.0 {
+			sweepAngle -= TWO_PI
+		}
+	}
+
+	return sweepAngle
+}
+
+func (gfxState *GraphicsState) newGraphicsObject(objectType GraphicsObjectType, apertureAttributes AttributeDictionary) *GraphicsObject {
+	if apertureAttributes == nil {
+		apertureAttributes = make(AttributeDictionary)
+	}
+
+	graphicsObject := &GraphicsObject{
+		objectType:         objectType,
+		polarity:           gfxState.currentLevelPolarity,
+		apertureAttributes: apertureAttributes,
+		objectAttributes:   gfxState.objectAttributes.copy(),
+	}
+	gfxState.graphicsObjects = append(gfxState.graphicsObjects, graphicsObject)
+
+	return graphicsObject
+}
+
+func (graphicsObject *GraphicsObject) GetObjectType() GraphicsObjectType {
+	return graphicsObject.objectType
+}
+
+// GetPolarity returns the polarity the object is drawn with, after taking the image polarity into account
+func (graphicsObject *GraphicsObject) GetPolarity() Polarity {
+	return graphicsObject.polarity
+}
+
+// GetStart returns the start point of a draw, arc or region, or the location of a flash
+func (graphicsObject *GraphicsObject) GetStart() Point {
+	return graphicsObject.start
+}
+
+// GetEnd returns the end point of a draw, arc or region, or the location of a flash
+func (graphicsObject *GraphicsObject) GetEnd() Point {
+	return graphicsObject.end
+}
+
+// GetCenter returns the center of an arc, and whether it runs clockwise
+func (graphicsObject *GraphicsObject) GetCenter() (center Point, clockwise bool) {
+	return graphicsObject.center, graphicsObject.sweepAngle < 0.0
+}
+
+// GetSweepAngle returns how far an arc turns about its center, in radians.  It is positive for counterclockwise arcs
+func (graphicsObject *GraphicsObject) GetSweepAngle() float64 {
+	return graphicsObject.sweepAngle
+}
+
+// GetContour returns the segments of a region's contour, which starts at GetStart
+func (graphicsObject *GraphicsObject) GetContour() []ContourSegment {
+	return graphicsObject.contour
+}
+
+// GetVertices returns the vertices of a region's contour, starting with its start point.  Arc segments in the contour
+// are represented by their end points only
+func (graphicsObject *GraphicsObject) GetVertices() []Point {
+	vertices := make([]Point, 0, len(graphicsObject.contour)+1)
+	vertices = append(vertices, graphicsObject.start)
+	for _, segment := range graphicsObject.contour {
+		vertices = append(vertices, segment.End)
+	}
+
+	return vertices
+}
+
+// GetApertureNumber returns the D code of the aperture used to create the object.  Regions aren't created with an aperture,
+// so this is meaningless for them
+func (graphicsObject *GraphicsObject) GetApertureNumber() int {
+	return graphicsObject.apertureNumber
+}
+
+// GetAperture returns the aperture used to create a flash, draw or arc, along with the transform that maps its shape into
+// the image.  For regions, the aperture is nil
+func (graphicsObject *GraphicsObject) GetAperture() (aperture Aperture, apertureTransform AffineTransform) {
+	return graphicsObject.aperture, graphicsObject.apertureTransform
+}
+
+// GetApertureMacro returns the macro definition used by a macro aperture, or nil for any other aperture
+func (graphicsObject *GraphicsObject) GetApertureMacro() []ApertureMacroDataBlock {
+	return graphicsObject.apertureMacro
+}
+
+func (graphicsObject *GraphicsObject) GetApertureAttributes() AttributeDictionary {
+	return graphicsObject.apertureAttributes
+}
+
+func (graphicsObject *GraphicsObject) GetObjectAttributes() AttributeDictionary {
+	return graphicsObject.objectAttributes
+}
+
+// GetAperFunction returns the values of the .AperFunction aperture attribute, or nil if the object doesn't have one
+func (graphicsObject *GraphicsObject) GetAperFunction() []string {
+	return graphicsObject.apertureAttributes[".AperFunction"]
+}
+
+// GetNet returns the net name from the .N object attribute, or an empty string if the object isn't on a net
+func (graphicsObject *GraphicsObject) GetNet() string {
+	return graphicsObject.objectAttributes.GetFirst(".N")
+}
+
+// GetPin returns the component reference designator and pin number from the .P object attribute
+func (graphicsObject *GraphicsObject) GetPin() (refdes string, pin string, found bool) {
+	if values := graphicsObject.objectAttributes[".P"]; len(values) >= 2 {
+		return values[0], values[1], true
+	}
+
+	return "", "", false
+}
+
+// GetComponent returns the component reference designator from the .C object attribute, or an empty string if the
+// object doesn't belong to a component
+func (graphicsObject *GraphicsObject) GetComponent() string {
+	return graphicsObject.objectAttributes.GetFirst(".C")
+}
+
+func (objectType GraphicsObjectType) String() string {
+	switch objectType {
+	case FLASH_OBJECT:
+		return "Flash"
+
+	case DRAW_OBJECT:
+		return "Draw"
+
+	case ARC_OBJECT:
+		return "Arc"
+
+	case REGION_OBJECT:
+		return "Region"
+
+	default:
+		return "Unknown"
+	}
+}
+
+func (graphicsObject *GraphicsObject) String() string {
+	return fmt.Sprintf("{%s, Aperture: %d, Start: %v, End: %v, Aperture Attributes: %v, Object Attributes: %v}",
+		graphicsObject.objectType, graphicsObject.apertureNumber, graphicsObject.start, graphicsObject.end,
+		graphicsObject.apertureAttributes, graphicsObject.objectAttributes)
+}
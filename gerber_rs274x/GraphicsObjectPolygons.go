@@ -0,0 +1,120 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+)
+
+// GetPolygons converts a graphics object into polygons, in image coordinates (millimetres).  Flashes give the layers of
+// their aperture, and draws, arcs and regions give a single layer.  The layers' polarities only describe the shape of the
+// object, which is then drawn with the object's own polarity.  Arcs are approximated by chords that never stray more than
+// the tolerance (in millimetres) from the true curve
+func (graphicsObject *GraphicsObject) GetPolygons(tolerance float64) ([]PolygonLayer, error) {
+	if tolerance <= 0.0 {
+		return nil, fmt.Errorf("Chord tolerance must be positive, got %f", tolerance)
+	}
+
+	switch graphicsObject.objectType {
+	case FLASH_OBJECT:
+		if graphicsObject.aperture == nil {
+			return nil, fmt.Errorf("Attempt to convert flash with undefined aperture %d to polygons", graphicsObject.apertureNumber)
+		}
+
+		// The aperture is built in its own units, so the tolerance needs scaling to match
+		apertureTolerance := tolerance / graphicsObject.apertureTransform.Scale()
+		if layers, err := graphicsObject.aperture.getPolygons(graphicsObject.apertureMacro, apertureTolerance); err != nil {
+			return nil, err
+		} else {
+			placement := graphicsObject.apertureTransform
+			placement.x0, placement.y0 = graphicsObject.start.X, graphicsObject.start.Y
+			return transformLayers(layers, placement), nil
+		}
+
+	case DRAW_OBJECT, ARC_OBJECT:
+		shape, radius, isCircle, err := graphicsObject.getStrokeShape()
+		if err != nil {
+			return nil, err
+		}
+
+		var polygons []Polygon
+		if graphicsObject.objectType == DRAW_OBJECT || graphicsObject.sweepAngle == 0.0 {
+			polygons = []Polygon{strokeLinearPolygon(shape, radius, isCircle, graphicsObject.start, graphicsObject.end, tolerance)}
+		} else {
+			polygons = strokeArcPolygons(shape, radius, isCircle, graphicsObject.start, graphicsObject.center, graphicsObject.sweepAngle, tolerance)
+		}
+
+		return []PolygonLayer{{polygons, DARK_POLARITY}}, nil
+
+	case REGION_OBJECT:
+		return []PolygonLayer{{[]Polygon{graphicsObject.getRegionPolygon(tolerance)}, DARK_POLARITY}}, nil
+
+	default:
+		return nil, fmt.Errorf("Unknown graphics object type %d", graphicsObject.objectType)
+	}
+}
+
+// getStrokeShape returns the shape of the aperture a draw or arc is stroked with, in image coordinates centered on the
+// origin.  Circles are returned as just their radius, since they can be stroked exactly
+func (graphicsObject *GraphicsObject) getStrokeShape() (shape Polygon, radius float64, isCircle bool, err error) {
+	scale := graphicsObject.apertureTransform.Scale()
+
+	switch aperture := graphicsObject.aperture.(type) {
+	case *CircleAperture:
+		if aperture.Hole != nil {
+			return nil, 0.0, false, fmt.Errorf("Aperture %d can't be stroked, apertures with holes can't be used for draws", graphicsObject.apertureNumber)
+		}
+		return nil, scale * aperture.diameter / 2.0, true, nil
+
+	case nil:
+		return nil, 0.0, false, fmt.Errorf("Attempt to convert stroke with undefined aperture %d to polygons", graphicsObject.apertureNumber)
+	}
+
+	if shape, err = getApertureStrokeShape(graphicsObject.aperture, graphicsObject.apertureNumber); err != nil {
+		return nil, 0.0, false, err
+	}
+
+	return shape.Transform(graphicsObject.apertureTransform), 0.0, false, nil
+}
+
+// getApertureStrokeShape returns the outline of a rectangle aperture, in aperture coordinates centered on the origin.
+// Only solid circles and solid rectangles can be used for draws, so any other aperture, or one with a hole, is an error
+func getApertureStrokeShape(aperture Aperture, apertureNumber int) (Polygon, error) {
+	if aperture.GetHole() != nil {
+		return nil, fmt.Errorf("Aperture %d can't be stroked, apertures with holes can't be used for draws", apertureNumber)
+	}
+
+	rectangle, isRectangle := aperture.(*RectangleAperture)
+	if !isRectangle {
+		return nil, fmt.Errorf("Aperture %d can't be stroked, only circle and rectangle apertures can be used for draws", apertureNumber)
+	}
+
+	radiusX := rectangle.xSize / 2.0
+	radiusY := rectangle.ySize / 2.0
+	return rectanglePolygon(-radiusX, radiusX, -radiusY, radiusY), nil
+}
+
+// getRegionPolygon flattens a region's contour into a counterclockwise polygon
+func (graphicsObject *GraphicsObject) getRegionPolygon(tolerance float64) Polygon {
+	polygon := Polygon{graphicsObject.start}
+	previous := graphicsObject.start
+
+	for _, segment := range graphicsObject.contour {
+		if segment.IsArc && segment.SweepAngle != 0.0 {
+			radius := math.Hypot(previous.X-segment.Center.X, previous.Y-segment.Center.Y)
+			startAngle := math.Atan2(previous.Y-segment.Center.Y, previous.X-segment.Center.X)
+			points := arcPoints(segment.Center, radius, startAngle, segment.SweepAngle, tolerance)
+
+			// The first point is the previous point, and the last is snapped to the segment's end so the contour closes exactly
+			polygon = append(polygon, points[1:len(points)-1]...)
+		}
+		polygon = append(polygon, segment.End)
+		previous = segment.End
+	}
+
+	// Contours end where they start, so the last point is a repeat of the first
+	if len(polygon) > 1 && polygon[len(polygon)-1] == polygon[0] {
+		polygon = polygon[:len(polygon)-1]
+	}
+
+	return polygon.counterClockwise()
+}
@@ -1,43 +1,55 @@
 package gerber_rs274x
 
+import (
+	"fmt"
+)
+
 type ImageNameParameter struct {
+	dataBlockPosition
 	paramCode ParameterCode
 	name      string
 }
 
 type ImageRotationParameter struct {
+	dataBlockPosition
 	paramCode ParameterCode
 	rotation  int
 }
 
 type OffsetParameter struct {
+	dataBlockPosition
 	paramCode   ParameterCode
 	axisAOffset float64
 	axisBOffset float64
 }
 
 type AxisSelectParameter struct {
+	dataBlockPosition
 	paramCode ParameterCode
 	isAXBY    bool
 }
 
 type ImagePolarityParameter struct {
+	dataBlockPosition
 	paramCode ParameterCode
 	polarity  Polarity
 }
 
 type ScaleFactorParameter struct {
+	dataBlockPosition
 	paramCode  ParameterCode
 	axisAScale float64
 	axisBScale float64
 }
 
 type LevelNameParameter struct {
+	dataBlockPosition
 	paramCode ParameterCode
 	name      string
 }
 
 type MirrorImageParameter struct {
+	dataBlockPosition
 	paramCode   ParameterCode
 	axisAMirror bool
 	axisBMirror bool
@@ -78,3 +90,136 @@ func (levelName *LevelNameParameter) DataBlockPlaceholder() {
 func (mirrorImage *MirrorImageParameter) DataBlockPlaceholder() {
 
 }
+
+// The image and level names are informational only, so they don't change the graphics state or draw anything
+
+func (imageName *ImageNameParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	return nil
+}
+
+func (imageName *ImageNameParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return imageName.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+func (levelName *LevelNameParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	return nil
+}
+
+func (levelName *LevelNameParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return levelName.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+// The remaining image parameters just record their settings in the graphics state.  The transformation they describe
+// applies to the whole image, so it is set up from the bounds pass before the file is interpreted
+
+func (imageRotation *ImageRotationParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	gfxState.imageParams.rotation = imageRotation.rotation
+
+	return nil
+}
+
+func (imageRotation *ImageRotationParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return imageRotation.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+func (offset *OffsetParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	gfxState.imageParams.axisAOffset = offset.axisAOffset
+	gfxState.imageParams.axisBOffset = offset.axisBOffset
+
+	return nil
+}
+
+func (offset *OffsetParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return offset.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+func (axisSelect *AxisSelectParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	gfxState.imageParams.axisSwap = !axisSelect.isAXBY
+
+	return nil
+}
+
+func (axisSelect *AxisSelectParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return axisSelect.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+func (imagePolarity *ImagePolarityParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	gfxState.imageParams.polarity = imagePolarity.polarity
+
+	return nil
+}
+
+func (imagePolarity *ImagePolarityParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return imagePolarity.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+func (scaleFactor *ScaleFactorParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	gfxState.imageParams.axisAScale = scaleFactor.axisAScale
+	gfxState.imageParams.axisBScale = scaleFactor.axisBScale
+
+	return nil
+}
+
+func (scaleFactor *ScaleFactorParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return scaleFactor.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+func (mirrorImage *MirrorImageParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	gfxState.imageParams.axisAMirror = mirrorImage.axisAMirror
+	gfxState.imageParams.axisBMirror = mirrorImage.axisBMirror
+
+	return nil
+}
+
+func (mirrorImage *MirrorImageParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return mirrorImage.ProcessDataBlockBoundsCheck(nil, gfxState)
+}
+
+func (inParam *ImageNameParameter) String() string {
+	return fmt.Sprintf("{IN, Name: %s}", inParam.name)
+}
+
+func (lnParam *LevelNameParameter) String() string {
+	return fmt.Sprintf("{LN, Name: %s}", lnParam.name)
+}
+
+func (irParam *ImageRotationParameter) String() string {
+	return fmt.Sprintf("{IR, Rotation: %d}", irParam.rotation)
+}
+
+func (ofParam *OffsetParameter) String() string {
+	return fmt.Sprintf("{OF, A Offset: %f, B Offset: %f}", ofParam.axisAOffset, ofParam.axisBOffset)
+}
+
+func (asParam *AxisSelectParameter) String() string {
+	if asParam.isAXBY {
+		return "{AS, Axes: AXBY}"
+	}
+
+	return "{AS, Axes: AYBX}"
+}
+
+func (ipParam *ImagePolarityParameter) String() string {
+	var imagePolarity string
+
+	switch ipParam.polarity {
+	case DARK_POLARITY:
+		imagePolarity = "Positive"
+
+	case CLEAR_POLARITY:
+		imagePolarity = "Negative"
+
+	default:
+		imagePolarity = "Unknown"
+	}
+
+	return fmt.Sprintf("{IP, Polarity: %s}", imagePolarity)
+}
+
+func (sfParam *ScaleFactorParameter) String() string {
+	return fmt.Sprintf("{SF, A Scale: %f, B Scale: %f}", sfParam.axisAScale, sfParam.axisBScale)
+}
+
+func (miParam *MirrorImageParameter) String() string {
+	return fmt.Sprintf("{MI, A Mirror: %t, B Mirror: %t}", miParam.axisAMirror, miParam.axisBMirror)
+}
@@ -2,9 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	"math"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 func (gfxState *GraphicsState) String() string {
@@ -26,12 +23,15 @@ type GraphicsState struct {
 	currentY                 float64
 	currentLevelPolarity     Polarity
 	regionModeOn             bool
-	xImageSize               int
-	yImageSize               int
 	fileComplete             bool
 	coordinateNotation       CoordinateNotation
+	units                    Units
 	filePrecision            float64
-	ScalingParms
+	imageParams              ImageParameters
+
+	// While a step and repeat block is being replayed, the offset of the current copy, which is added to all absolute coordinates
+	stepOffsetX float64
+	stepOffsetY float64
 
 	// As we encounter aperture definitions, we save them
 	// for later use while drawing
@@ -39,16 +39,18 @@ type GraphicsState struct {
 	// We also need to remember aperture macro definitions, so that we can recall them when they are
 	// referenced in aperture definition parameters
 	apertureMacros map[string][]ApertureMacroDataBlock
-	// The first time an aperture is rendered, we render it to a cairo surface
-	// Then, we can just look up the rendered aperture the next time we need it
-	// This should provide for some optimization, since the same aperture will
-	// get used over and over to stroke a path
-	renderedApertures map[int]*cairo.Surface
-	// We also need to save apertures rendered without their holes (if they have holes), for use in certain
-	// optimized stroke drawing routines.  If a particular aperture doesn't have a hole, a reference to the same
-	// surface as is stored in the renderedApertures map is stored here, to save on memory.  Else, a new rendered
-	// surface without the hole is stored here
-	renderedAperturesNoHoles map[int]*cairo.Surface
+
+	// Gerber X2 attribute dictionaries.  The current aperture dictionary is attached to each aperture as it is defined, and the
+	// current object dictionary is attached to each graphics object as it is created
+	fileAttributes            AttributeDictionary
+	apertureAttributes        AttributeDictionary
+	objectAttributes          AttributeDictionary
+	definedApertureAttributes map[int]AttributeDictionary
+
+	// The interpret pass records every graphics object the file creates, along with its attributes.  A region object
+	// stays open (and keeps collecting vertices) until its contour is ended by a move or by leaving region mode
+	graphicsObjects []*GraphicsObject
+	currentRegion   *GraphicsObject
 
 	// Some of these default to undefined,
 	// so we also need to keep track of when they get defined
@@ -58,34 +60,18 @@ type GraphicsState struct {
 	coordinateNotationSet bool
 }
 
-func newGraphicsState(bounds *ImageBounds, xImageSize int, yImageSize int) *GraphicsState {
+func newGraphicsState() *GraphicsState {
 	graphicsState := new(GraphicsState)
 
 	graphicsState.currentLevelPolarity = DARK_POLARITY
+	graphicsState.imageParams = newImageParameters()
+	graphicsState.fileAttributes = make(AttributeDictionary)
+	graphicsState.apertureAttributes = make(AttributeDictionary)
+	graphicsState.objectAttributes = make(AttributeDictionary)
+	graphicsState.definedApertureAttributes = make(map[int]AttributeDictionary, 10)
 	graphicsState.apertures = make(map[int]Aperture, 10)                         // Start with an initial capacity of 10 apertures, will grow as needed
-	graphicsState.renderedApertures = make(map[int]*cairo.Surface, 10)           // Same as above
-	graphicsState.renderedAperturesNoHoles = make(map[int]*cairo.Surface, 10)    // Same as above
 	graphicsState.apertureMacros = make(map[string][]ApertureMacroDataBlock, 10) // Same as above
 
-	if bounds != nil {
-		// If bounds are provided, compute the necessary scaling information
-		xSpan := bounds.xMax - bounds.xMin
-		ySpan := bounds.yMax - bounds.yMin
-
-		// Build 5% margin on each side into the scaling
-		xMargin := float64(xImageSize) * 0.1
-		yMargin := float64(yImageSize) * 0.1
-
-		// Compute the appropriate scaling factor
-		xScale := (float64(xImageSize) - xMargin) / xSpan
-		yScale := (float64(yImageSize) - yMargin) / ySpan
-		graphicsState.scaleFactor = math.Min(xScale, yScale)
-
-		// Compute offsets to apply to all coordinates to start them at zero and account for margins
-		graphicsState.xOffset = -(bounds.xMin * graphicsState.scaleFactor) + (xMargin / 2.0)
-		graphicsState.yOffset = -(bounds.yMin * graphicsState.scaleFactor) + (yMargin / 2.0)
-	}
-
 	// All other settings are fine with their go defaults
 	// Current aperture: Doesn't matter since it's undefined by default
 	// Current quadrant mode: Doesn't matter since it's undefined by default
@@ -108,31 +94,3 @@ func (gfxState *GraphicsState) updateCurrentCoordinate(newX float64, newY float6
 	gfxState.currentX = newX
 	gfxState.currentY = newY
 }
-
-func (gfxState *GraphicsState) releaseRenderedSurfaces() {
-
-	// NOTE: For the purposes of this iteration, we assume that the keyset of renderedApertures is a strict superset
-	// of the keyset of renderedAperturesNoHoles (which should always be the case).  If this assumption is broken,
-	// the iteration may miss releasing some surfaces in the renderedAperturesNoHoles map
-	for apertureNumber, surface1 := range gfxState.renderedApertures {
-		if surface2, found := gfxState.renderedAperturesNoHoles[apertureNumber]; found {
-			// This aperture has been rendered into both the holes and no-holes map
-			// Next, we need to check whether both surfaces actually refer to the same object,
-			// and if so, only release it once
-			if surface1 == surface2 {
-				surface1.Finish()
-				surface1.Destroy()
-			} else {
-				// Else, they're two separate objects and we must release them both
-				surface1.Finish()
-				surface1.Destroy()
-				surface2.Finish()
-				surface2.Destroy()
-			}
-		} else {
-			// This aperture was only rendered into the holes map
-			surface1.Finish()
-			surface1.Destroy()
-		}
-	}
-}
@@ -2,19 +2,13 @@ package gerber_rs274x
 
 import (
 	"fmt"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type GraphicsStateChange struct {
+	dataBlockPosition
 	fnCode FunctionCode
 }
 
-func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockToolpath(camo *CamOutput, gfxState *GraphicsState) error {
-	graphicsStateChange.ProcessDataBlockSurface(nil, gfxState)
-	return nil
-}
-
 func (graphicsStateChange *GraphicsStateChange) DataBlockPlaceholder() {
 
 }
@@ -33,39 +27,25 @@ func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockBoundsCheck(imag
 	case END_OF_FILE:
 		gfxState.fileComplete = true
 
+	case SET_UNIT_INCH:
+		gfxState.units = UNITS_IN
+
+	case SET_UNIT_MM:
+		gfxState.units = UNITS_MM
+
 		// For now, we're not going to do anything with any of the other ones
 	}
 
 	return nil
 }
 
-func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	switch graphicsStateChange.fnCode {
-	case SINGLE_QUADRANT_MODE, MULTI_QUADRANT_MODE:
-		gfxState.currentQuadrantMode = graphicsStateChange.fnCode
-
-	case REGION_MODE_ON:
-		gfxState.regionModeOn = true
-
-	case REGION_MODE_OFF:
-		gfxState.regionModeOn = false
-		// If we're turning region mode off, we need to close and draw any contours in progress
-		switch gfxState.currentLevelPolarity {
-		case DARK_POLARITY:
-			surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-
-		case CLEAR_POLARITY:
-			surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
-		}
-		surface.Fill()
-
-	case END_OF_FILE:
-		gfxState.fileComplete = true
-
-		// For now, we're not going to do anything with any of the other ones
+func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	// Entering or leaving region mode ends the region being recorded, so the next contour starts a new region object
+	if graphicsStateChange.fnCode == REGION_MODE_ON || graphicsStateChange.fnCode == REGION_MODE_OFF {
+		gfxState.currentRegion = nil
 	}
 
-	return nil
+	return graphicsStateChange.ProcessDataBlockBoundsCheck(nil, gfxState)
 }
 
 func (graphicsStateChange *GraphicsStateChange) String() string {
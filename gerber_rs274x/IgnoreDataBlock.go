@@ -2,11 +2,10 @@ package gerber_rs274x
 
 import (
 	"fmt"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type IgnoreDataBlock struct {
+	dataBlockPosition
 	comment string
 }
 
@@ -19,14 +18,8 @@ func (ignoreDataBlock *IgnoreDataBlock) ProcessDataBlockBoundsCheck(imageBounds
 	return nil
 }
 
-func (ignoreDataBlock *IgnoreDataBlock) ProcessDataBlockToolpath(*CamOutput, *GraphicsState) error {
-	// This is a comment, so it doesn't change the graphics state or draw anything
-	return nil
-}
-
-func (ignoreDataBlock *IgnoreDataBlock) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	// This is a comment, so it doesn't change the graphics state or draw anything
-	return nil
+func (ignoreDataBlock *IgnoreDataBlock) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return ignoreDataBlock.ProcessDataBlockBoundsCheck(nil, gfxState)
 }
 
 func (ignoreDataBlock *IgnoreDataBlock) String() string {
@@ -0,0 +1,10 @@
+package gerber_rs274x
+
+// ImageBackend is implemented by anything that can output an interpreted gerber image (a renderer, a toolpath
+// generator, an exporter to another format).  BeginImage is called once before any objects are drawn, so the backend
+// can size its output from the image, and EndImage once after the last object
+type ImageBackend interface {
+	BeginImage(image *GerberImage) error
+	DrawObject(graphicsObject *GraphicsObject) error
+	EndImage() error
+}
@@ -60,3 +60,14 @@ func (bounds *ImageBounds) updateBoundsAperture(currentX float64, currentY float
 	// Use update bounds to do the actual work
 	bounds.updateBounds(xMin, xMax, yMin, yMax)
 }
+
+// toMillimetres returns a copy of bounds measured in the given file units, converted to millimetres
+func (bounds *ImageBounds) toMillimetres(units Units) *ImageBounds {
+	converted := *bounds
+	if units == UNITS_IN {
+		converted.xMin, converted.xMax = converted.xMin*MM_PER_INCH, converted.xMax*MM_PER_INCH
+		converted.yMin, converted.yMax = converted.yMin*MM_PER_INCH, converted.yMax*MM_PER_INCH
+	}
+
+	return &converted
+}
@@ -0,0 +1,85 @@
+package gerber_rs274x
+
+// ImageParameters holds the state set by the deprecated image parameters (AS, MI, SF, OF, IR and IP).  These apply to the
+// image as a whole, no matter where in the file they appear, so the bounds pass collects them and the interpret pass
+// applies them to every object it records
+type ImageParameters struct {
+	axisSwap    bool
+	axisAMirror bool
+	axisBMirror bool
+	axisAScale  float64
+	axisBScale  float64
+	axisAOffset float64
+	axisBOffset float64
+	rotation    int
+	polarity    Polarity
+}
+
+func newImageParameters() ImageParameters {
+	return ImageParameters{axisAScale: 1.0, axisBScale: 1.0, polarity: DARK_POLARITY}
+}
+
+// transformPoint maps a point from file coordinates to image coordinates.  The image parameters are applied in the order
+// axis select, mirror, scale, offset, and then rotation about the origin
+func (imageParams *ImageParameters) transformPoint(x float64, y float64) (float64, float64) {
+	a, b := x, y
+	if imageParams.axisSwap {
+		a, b = y, x
+	}
+
+	if imageParams.axisAMirror {
+		a = -a
+	}
+
+	if imageParams.axisBMirror {
+		b = -b
+	}
+
+	a = (a * imageParams.axisAScale) + imageParams.axisAOffset
+	b = (b * imageParams.axisBScale) + imageParams.axisBOffset
+
+	// Image rotation is restricted to multiples of 90 degrees, so we can rotate exactly
+	switch imageParams.rotation {
+	case 90:
+		return -b, a
+
+	case 180:
+		return -a, -b
+
+	case 270:
+		return b, -a
+
+	default:
+		return a, b
+	}
+}
+
+// effectivePolarity returns the polarity to actually draw with for the given level polarity.  In a negative image,
+// dark and clear are swapped
+func (imageParams *ImageParameters) effectivePolarity(levelPolarity Polarity) Polarity {
+	if imageParams.polarity == DARK_POLARITY {
+		return levelPolarity
+	}
+
+	if levelPolarity == DARK_POLARITY {
+		return CLEAR_POLARITY
+	}
+
+	return DARK_POLARITY
+}
+
+// transformBounds returns the bounding box of the given bounds once they have been mapped to image coordinates.  Since
+// none of the image parameters can introduce arbitrary rotations, mapping the corners is enough to find the new bounding box
+func (imageParams *ImageParameters) transformBounds(bounds *ImageBounds) *ImageBounds {
+	transformedBounds := newImageBounds()
+	if !bounds.boundsSet {
+		return transformedBounds
+	}
+
+	for _, corner := range [][2]float64{{bounds.xMin, bounds.yMin}, {bounds.xMin, bounds.yMax}, {bounds.xMax, bounds.yMin}, {bounds.xMax, bounds.yMax}} {
+		x, y := imageParams.transformPoint(corner[0], corner[1])
+		transformedBounds.updateBounds(x, x, y, y)
+	}
+
+	return transformedBounds
+}
@@ -3,11 +3,10 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type Interpolation struct {
+	dataBlockPosition
 	fnCode      FunctionCode
 	opCode      OperationCode
 	x           float64
@@ -20,245 +19,6 @@ type Interpolation struct {
 	yValid      bool
 }
 
-func (interpolation *Interpolation) flashMacro(camo *CamOutput, gfxState *GraphicsState, mac *MacroAperture) {
-	fmt.Println("\tMMM", mac)
-	apNum := fmt.Sprintf("%d", gfxState.currentAperture)
-	macs := gfxState.apertureMacros[apNum]
-	fmt.Println("\tMMM", macs)
-	fmt.Println("\tMMM", macs)
-	rect := &RectangleAperture{
-		xSize: mac.xMax - mac.xMin,
-		ySize: mac.yMax - mac.yMin,
-	}
-	interpolation.flashRectangle(camo, gfxState, rect)
-}
-
-func (interpolation *Interpolation) flashRectangleTall(camo *CamOutput, gfxState *GraphicsState, rect *RectangleAperture) {
-	fmt.Println("\tRRR", rect)
-	// cx, cy := camo.translateScale(interpolation.x, interpolation.y)
-	cx, cy := interpolation.x, interpolation.y
-	xsiz := rect.xSize
-	ysiz := rect.ySize
-	tw := camo.toolWidth
-	tw2 := tw / 2.0
-
-	dx := xsiz / 2.0
-	dy := ysiz / 2.0
-	fmt.Println(camo.wrt, "; Rectangle: cx = ", cx, ", cy = ", cy, ", xsiz = ", xsiz, ", ysiz = ", ysiz)
-	fmt.Fprintln(camo.wrt, "\t\t; Rectangle: cx = ", cx, ", cy = ", cy, ", xsiz = ", xsiz, ", ysiz = ", ysiz)
-	x := -dx
-	x0, y0 := camo.translateScale(cx+x, cy-dy)
-	fmt.Fprintf(camo.wrt, "G00X%fY%f\n", x0, y0)
-	fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
-	for ; x <= dx; x += tw {
-		x0, y0 = camo.translateScale(cx+x, cy+dy)
-		x1, y1 := camo.translateScale(cx+x+tw2, cy+dy)
-		x2, y2 := camo.translateScale(cx+x+tw2, cy-dy)
-		x3, y3 := camo.translateScale(cx+x+tw, cy-dy)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0, y0, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1, y1, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x2, y2, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x3, y3, camo.feedrate)
-	}
-	fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0, y0, camo.feedrate)
-	fmt.Fprintf(camo.wrt, "M05\n")
-}
-
-func (interpolation *Interpolation) flashRectangleWide(camo *CamOutput, gfxState *GraphicsState, rect *RectangleAperture) {
-	fmt.Println("\tRRR", rect)
-	// cx, cy := camo.translateScale(interpolation.x, interpolation.y)
-	cx, cy := interpolation.x, interpolation.y
-	xsiz := rect.xSize
-	ysiz := rect.ySize
-	tw := camo.toolWidth
-
-	dx := xsiz / 2.0
-	dy := ysiz / 2.0
-	fmt.Println(camo.wrt, "; Rectangle: cx = ", cx, ", cy = ", cy, ", xsiz = ", xsiz, ", ysiz = ", ysiz)
-	fmt.Fprintln(camo.wrt, "\t\t; Rectangle: cx = ", cx, ", cy = ", cy, ", xsiz = ", xsiz, ", ysiz = ", ysiz)
-
-	y := -dy
-	x0, y0 := camo.translateScale(cx-dx, cy+y)
-	fmt.Fprintf(camo.wrt, "G00X%fY%f\n", x0, y0)
-	fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
-
-	tw2 := tw / 2.0
-	for ; y <= dy; y += tw {
-		x0, y0 = camo.translateScale(cx+dx, cy+y)
-		x1, y1 := camo.translateScale(cx+dx, cy+y+tw2)
-		x2, y2 := camo.translateScale(cx-dx, cy+y+tw2)
-		x3, y3 := camo.translateScale(cx-dx, cy+y+tw)
-
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0, y0, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1, y1, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x2, y2, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x3, y3, camo.feedrate)
-	}
-	fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0, y0, camo.feedrate)
-	fmt.Fprintf(camo.wrt, "M05\n")
-}
-
-func (interpolation *Interpolation) flashRectangle(camo *CamOutput, gfxState *GraphicsState, rect *RectangleAperture) {
-	if rect.xSize < rect.ySize {
-		interpolation.flashRectangleTall(camo, gfxState, rect)
-	} else {
-		interpolation.flashRectangleWide(camo, gfxState, rect)
-	}
-}
-
-func flashCircle(cx, cy, dia float64, camo *CamOutput, gfxState *GraphicsState) {
-	// cx, cy = camo.translateScale(cx, cy)
-	fmt.Println(camo.wrt, "; Circle: cx = ", cx, ", cy = ", cy, ", dia = ", dia)
-	rad := dia / 2.0
-	fmt.Println("\t\tcx = ", cx, ", cy = ", cy, ", dia = ", dia)
-
-	x0, y0 := camo.translateScale(cx-rad, cy)
-	fmt.Fprintf(camo.wrt, "G00X%fY%f\n", x0, y0)
-	fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
-
-	var tw = camo.toolWidth
-	tw2 := tw / 2.0
-
-	for dx := -rad + tw2; dx < rad; dx += tw2 {
-		dy := math.Sqrt(math.Pow(rad, 2.0) - math.Pow(dx, 2.0))
-		x1, y1 := camo.translateScale(cx+dx, cy+dy)
-		x2, y2 := camo.translateScale(cx+dx, cy-dy)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1, y1, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x2, y2, camo.feedrate)
-	}
-	x3, y3 := camo.translateScale(cx+rad, cy)
-	fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x3, y3, camo.feedrate)
-	fmt.Fprintf(camo.wrt, "M05\n")
-}
-func (interpolation *Interpolation) flashObround(camo *CamOutput, gfxState *GraphicsState, obro *ObroundAperture) {
-	fmt.Println("\tOOO", obro)
-	fmt.Fprintln(camo.wrt, "; ======= HACK =====")
-	rect := &RectangleAperture{
-		xSize: obro.xSize,
-		ySize: obro.ySize,
-	}
-	interpolation.flashRectangle(camo, gfxState, rect)
-}
-
-func (interpolation *Interpolation) flashOp(camo *CamOutput, gfxState *GraphicsState) {
-	fmt.Println("\t", gfxState)
-	fmt.Println("\t", interpolation)
-	ap := gfxState.apertures[gfxState.currentAperture]
-	switch ap.(type) {
-	case *MacroAperture:
-		fmt.Println("\t", ap.(*MacroAperture))
-		interpolation.flashMacro(camo, gfxState, ap.(*MacroAperture))
-	case *RectangleAperture:
-		fmt.Println("\t", ap.(*RectangleAperture))
-		interpolation.flashRectangle(camo, gfxState, ap.(*RectangleAperture))
-	case *CircleAperture:
-		fmt.Println("\t", ap.(*CircleAperture))
-		flashCircle(interpolation.x, interpolation.y, ap.(*CircleAperture).diameter, camo, gfxState)
-	case *ObroundAperture:
-		fmt.Println("\t", ap.(*ObroundAperture))
-		interpolation.flashObround(camo, gfxState, ap.(*ObroundAperture))
-	default:
-		fmt.Printf("\tXXXX %T", ap)
-	}
-}
-
-func (interpolation *Interpolation) gcodeG00(camo *CamOutput) {
-
-	cx, cy := camo.translateScale(interpolation.x, interpolation.y)
-	fmt.Fprintf(camo.wrt, "G00")
-	if interpolation.xValid {
-		fmt.Fprintf(camo.wrt, "X%f", cx)
-	}
-	if interpolation.yValid {
-		fmt.Fprintf(camo.wrt, "Y%f", cy)
-	}
-	fmt.Fprintf(camo.wrt, "\n")
-}
-
-func (interpolation *Interpolation) makeTrace(camo *CamOutput, gfxState *GraphicsState) {
-	x0, y0 := camo.x, camo.y
-	x1, y1 := x0, y0
-	if interpolation.xValid {
-		x1 = interpolation.x
-	}
-	if interpolation.yValid {
-		y1 = interpolation.y
-	}
-	apn := gfxState.currentAperture
-	ap := gfxState.apertures[apn].(*CircleAperture)
-	dia := ap.diameter
-
-	flashCircle(x1, y1, dia, camo, gfxState)
-	flashCircle(x0, y0, dia, camo, gfxState)
-
-	// A little hacky, but, flashCircle also does the translate/scale
-	x0, y0 = camo.translateScale(x0, y0)
-	x1, y1 = camo.translateScale(x1, y1)
-
-	r := dia / 2
-	dx := x1 - x0
-	dy := y1 - y0
-	dist := math.Sqrt(dx*dx + dy*dy)
-	if dist < camo.toolWidth {
-		fmt.Println("Trace too short")
-		return
-	}
-	sin := dx / dist
-	cos := dy / dist
-	fmt.Fprintln(camo.wrt, "; makeTrace ",
-		", x0 = ", x0, ", y0 = ", y0,
-		", x1 = ", x1, ", y1 = ", y1,
-		", dx = ", dx, ", dy = ", dy,
-		", dist = ", dist, ", wid = ", dia,
-		", dia = ", dia, ", r = ", r,
-		", sin = ", sin, ", cos = ", cos)
-	fmt.Println("; makeTrace ",
-		", x0 = ", x0, ", y0 = ", y0,
-		", x1 = ", x1, ", y1 = ", y1,
-		", dx = ", dx, ", dy = ", dy,
-		", dist = ", dist, ", wid = ", dia,
-		", dia = ", dia, ", r = ", r,
-		", sin = ", sin, ", cos = ", cos)
-
-	fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
-	for i0 := -r; i0 < r; i0 += camo.toolWidth {
-		i1 := i0 + camo.toolWidth/2.0
-
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0-i0*cos, y0+i0*sin, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1-i0*cos, y1+i0*sin, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1-i1*cos, y1+i1*sin, camo.feedrate)
-		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0-i1*cos, y0+i1*sin, camo.feedrate)
-	}
-	fmt.Fprintf(camo.wrt, "M05\n")
-}
-
-func (interpolation *Interpolation) ProcessDataBlockToolpath(camo *CamOutput, gfxState *GraphicsState) error {
-	if interpolation.fnCodeValid {
-		switch interpolation.fnCode {
-		case LINEAR_INTERPOLATION, CIRCULAR_INTERPOLATION_CLOCKWISE, CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
-			gfxState.currentInterpolationMode = interpolation.fnCode
-			gfxState.interpolationModeSet = true
-		}
-	}
-	if interpolation.opCodeValid {
-		switch {
-		case interpolation.opCode == MOVE_OPERATION:
-			fmt.Println("case interpolation.opCode == MOVE_OPERATION:")
-			interpolation.gcodeG00(camo)
-		case interpolation.opCode == INTERPOLATE_OPERATION:
-			fmt.Println("case interpolation.opCode == INTERPOLATE_OPERATION:", gfxState.currentAperture)
-			interpolation.makeTrace(camo, gfxState)
-		case interpolation.opCode == FLASH_OPERATION:
-			fmt.Println("case interpolation.opCode == FLASH_OPERATION:", gfxState.currentAperture)
-			interpolation.gcodeG00(camo)
-			interpolation.flashOp(camo, gfxState)
-		}
-		camo.x = interpolation.x
-		camo.y = interpolation.y
-	}
-	return nil
-}
-
 func (interpolation *Interpolation) DataBlockPlaceholder() {
 
 }
@@ -280,6 +40,21 @@ func (interpolation *Interpolation) ProcessDataBlockBoundsCheck(bounds *ImageBou
 		} else {
 			switch interpolation.opCode {
 			case INTERPOLATE_OPERATION:
+				if gfxState.regionModeOn {
+					// Region contours aren't stroked with an aperture, so they don't need one to be set
+					//TODO: Do a better job than this, this is just a quick hack
+					//It works for linear segments, but not for arcs
+					xMin := math.Min(gfxState.currentX, move.newX)
+					xMax := math.Max(gfxState.currentX, move.newX)
+					yMin := math.Min(gfxState.currentY, move.newY)
+					yMax := math.Max(gfxState.currentY, move.newY)
+					bounds.updateBounds(xMin, xMax, yMin, yMax)
+
+					// Update the graphics state with the new end coordinate
+					gfxState.updateCurrentCoordinate(move.newX, move.newY)
+					break
+				}
+
 				if !gfxState.apertureSet {
 					return fmt.Errorf("Attempt to check interpolation bounds before aperture set")
 				}
@@ -289,265 +64,258 @@ func (interpolation *Interpolation) ProcessDataBlockBoundsCheck(bounds *ImageBou
 				} else {
 					apertureMinSize := aperture.GetMinSize(gfxState)
 
-					if gfxState.regionModeOn {
-						//TODO: Do a better job than this, this is just a quick hack
-						//It works for linear segments, but not for arcs
-						xMin := math.Min(gfxState.currentX, move.newX)
-						xMax := math.Max(gfxState.currentX, move.newX)
-						yMin := math.Min(gfxState.currentY, move.newY)
-						yMax := math.Max(gfxState.currentY, move.newY)
-						bounds.updateBounds(xMin, xMax, yMin, yMax)
+					switch gfxState.currentInterpolationMode {
+					case LINEAR_INTERPOLATION:
+						// Update the bounds with both endpoints
+						bounds.updateBoundsAperture(gfxState.currentX, gfxState.currentY, apertureMinSize)
+						bounds.updateBoundsAperture(move.newX, move.newY, apertureMinSize)
 
-						// Update the graphics state with the new end coordinate
+						// Finally, update the graphics state with the new end coordinate
 						gfxState.updateCurrentCoordinate(move.newX, move.newY)
-					} else {
-						switch gfxState.currentInterpolationMode {
-						case LINEAR_INTERPOLATION:
-							// Update the bounds with both endpoints
-							bounds.updateBoundsAperture(gfxState.currentX, gfxState.currentY, apertureMinSize)
-							bounds.updateBoundsAperture(move.newX, move.newY, apertureMinSize)
-
-							// Finally, update the graphics state with the new end coordinate
-							gfxState.updateCurrentCoordinate(move.newX, move.newY)
-
-						case CIRCULAR_INTERPOLATION_CLOCKWISE, CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
-							radius := math.Hypot(move.newX-move.centerX, move.newY-move.centerY)
-
-							// Update the bounds with both endpoints
-							bounds.updateBoundsAperture(gfxState.currentX, gfxState.currentY, apertureMinSize)
-							bounds.updateBoundsAperture(move.newX, move.newY, apertureMinSize)
-
-							// Special case, if the angles are equal, and we're in multi quadrant mode, we're drawing a full circle,
-							// so the arc spans all of the axes
-							if epsilonEquals(move.startAngle, move.endAngle, gfxState.filePrecision) && (gfxState.currentQuadrantMode == MULTI_QUADRANT_MODE) {
-								bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize) // positive y-axis
-								bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize) // positive x-axis
-								bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize) // negative y-axis
-								bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize) // negative x-axis
-							} else {
-								// Otherwise, if the two angles span one (or more, depending on quadrant mode) of the axes, also update the bounds with the point
-								// along that axis at a distance of the radius of the arc (the max distance in that direction that the arc will cover)
-								switch gfxState.currentQuadrantMode {
-								case SINGLE_QUADRANT_MODE:
-									if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE {
-										if inQuadrant(move.startAngle, QUADRANT_2) && inQuadrant(move.endAngle, QUADRANT_1) {
-											// The angle spans the positive y-axis
-											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-										}
 
-										if inQuadrant(move.startAngle, QUADRANT_1) && inQuadrant(move.endAngle, QUADRANT_4) {
+					case CIRCULAR_INTERPOLATION_CLOCKWISE, CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
+						radius := math.Hypot(move.newX-move.centerX, move.newY-move.centerY)
+
+						// Update the bounds with both endpoints
+						bounds.updateBoundsAperture(gfxState.currentX, gfxState.currentY, apertureMinSize)
+						bounds.updateBoundsAperture(move.newX, move.newY, apertureMinSize)
+
+						// Special case, if the angles are equal, and we're in multi quadrant mode, we're drawing a full circle,
+						// so the arc spans all of the axes
+						if epsilonEquals(move.startAngle, move.endAngle, gfxState.filePrecision) && (gfxState.currentQuadrantMode == MULTI_QUADRANT_MODE) {
+							bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize) // positive y-axis
+							bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize) // positive x-axis
+							bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize) // negative y-axis
+							bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize) // negative x-axis
+						} else {
+							// Otherwise, if the two angles span one (or more, depending on quadrant mode) of the axes, also update the bounds with the point
+							// along that axis at a distance of the radius of the arc (the max distance in that direction that the arc will cover)
+							switch gfxState.currentQuadrantMode {
+							case SINGLE_QUADRANT_MODE:
+								if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE {
+									if inQuadrant(move.startAngle, QUADRANT_2) && inQuadrant(move.endAngle, QUADRANT_1) {
+										// The angle spans the positive y-axis
+										bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+									}
+
+									if inQuadrant(move.startAngle, QUADRANT_1) && inQuadrant(move.endAngle, QUADRANT_4) {
+										// The angle spans the positive x-axis
+										bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+									}
+
+									if inQuadrant(move.startAngle, QUADRANT_4) && inQuadrant(move.endAngle, QUADRANT_3) {
+										// The angle spans the negative y-axis
+										bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+									}
+
+									if inQuadrant(move.startAngle, QUADRANT_3) && inQuadrant(move.endAngle, QUADRANT_2) {
+										// The angle spans the negative x-axis
+										bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+									}
+								} else {
+									if inQuadrant(move.startAngle, QUADRANT_1) && inQuadrant(move.endAngle, QUADRANT_2) {
+										// The angle spans the positive y-axis
+										bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+									}
+
+									if inQuadrant(move.startAngle, QUADRANT_4) && inQuadrant(move.endAngle, QUADRANT_1) {
+										// The angle spans the positive x-axis
+										bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+									}
+
+									if inQuadrant(move.startAngle, QUADRANT_3) && inQuadrant(move.endAngle, QUADRANT_4) {
+										// The angle spans the negative y-axis
+										bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+									}
+
+									if inQuadrant(move.startAngle, QUADRANT_2) && inQuadrant(move.endAngle, QUADRANT_3) {
+										// The angle spans the negative x-axis
+										bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+									}
+								}
+
+							case MULTI_QUADRANT_MODE:
+								if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE {
+									if inQuadrant(move.startAngle, QUADRANT_1) {
+										if inQuadrant(move.endAngle, QUADRANT_4) {
 											// The angle spans the positive x-axis
 											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-										}
-
-										if inQuadrant(move.startAngle, QUADRANT_4) && inQuadrant(move.endAngle, QUADRANT_3) {
-											// The angle spans the negative y-axis
+										} else if inQuadrant(move.endAngle, QUADRANT_3) {
+											// The angle spans the positive x-axis and negative y-axis
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_4) {
+											// The angle spans the positive x-axis, negative y-axis, and negative x-axis
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
 											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-										}
-
-										if inQuadrant(move.startAngle, QUADRANT_3) && inQuadrant(move.endAngle, QUADRANT_2) {
-											// The angle spans the negative x-axis
 											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_1) && (move.endAngle > move.startAngle) {
+											// The angle spans all 4 axes
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
 										}
-									} else {
-										if inQuadrant(move.startAngle, QUADRANT_1) && inQuadrant(move.endAngle, QUADRANT_2) {
+									} else if inQuadrant(move.startAngle, QUADRANT_2) {
+										if inQuadrant(move.endAngle, QUADRANT_1) {
 											// The angle spans the positive y-axis
 											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_4) {
+											// The angle spans the positive y-axis and positive x-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_3) {
+											// The angle spans the positive y-axis, positive x-axis, and negative y-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_2) && (move.endAngle > move.startAngle) {
+											// The angle spans all 4 axes
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
 										}
-
-										if inQuadrant(move.startAngle, QUADRANT_4) && inQuadrant(move.endAngle, QUADRANT_1) {
-											// The angle spans the positive x-axis
+									} else if inQuadrant(move.startAngle, QUADRANT_3) {
+										if inQuadrant(move.endAngle, QUADRANT_2) {
+											// The angle spans the negative x-axis
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_1) {
+											// The angle spans the negative x-axis and positive y-axis
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_4) {
+											// The angle spans the negative x-axis, positive y-axis, and positive x-axis
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_3) && (move.endAngle > move.startAngle) {
+											// The angle spans all 4 axes
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
 											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
 										}
-
-										if inQuadrant(move.startAngle, QUADRANT_3) && inQuadrant(move.endAngle, QUADRANT_4) {
+									} else if inQuadrant(move.startAngle, QUADRANT_4) {
+										if inQuadrant(move.endAngle, QUADRANT_3) {
 											// The angle spans the negative y-axis
 											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_2) {
+											// The angle spans the negative y-axis and negative x-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_1) {
+											// The angle spans the negative y-axis, negative x-axis, and positive y-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_4) && (move.endAngle > move.startAngle) {
+											// The angle spans all 4 axes
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
 										}
-
-										if inQuadrant(move.startAngle, QUADRANT_2) && inQuadrant(move.endAngle, QUADRANT_3) {
+									}
+								} else {
+									if inQuadrant(move.startAngle, QUADRANT_1) {
+										if inQuadrant(move.endAngle, QUADRANT_2) {
+											// The angle spans the positive y-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_3) {
+											// The angle spans the positive y-axis and negative x-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_4) {
+											// The angle spans the positive y-axis, negative x-axis, and negative y-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_1) && (move.endAngle < move.startAngle) {
+											// The angle spans all 4 axes
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+										}
+									} else if inQuadrant(move.startAngle, QUADRANT_2) {
+										if inQuadrant(move.endAngle, QUADRANT_3) {
 											// The angle spans the negative x-axis
 											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_4) {
+											// The angle spans the negative x-axis and negative y-axis
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_1) {
+											// The angle spans the negative x-axis, negative y-axis, and positive x-axis
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_2) && (move.endAngle < move.startAngle) {
+											// The angle spans all 4 axes
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
 										}
-									}
-
-								case MULTI_QUADRANT_MODE:
-									if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE {
-										if inQuadrant(move.startAngle, QUADRANT_1) {
-											if inQuadrant(move.endAngle, QUADRANT_4) {
-												// The angle spans the positive x-axis
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_3) {
-												// The angle spans the positive x-axis and negative y-axis
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_4) {
-												// The angle spans the positive x-axis, negative y-axis, and negative x-axis
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_1) && (move.endAngle > move.startAngle) {
-												// The angle spans all 4 axes
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-											}
-										} else if inQuadrant(move.startAngle, QUADRANT_2) {
-											if inQuadrant(move.endAngle, QUADRANT_1) {
-												// The angle spans the positive y-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_4) {
-												// The angle spans the positive y-axis and positive x-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_3) {
-												// The angle spans the positive y-axis, positive x-axis, and negative y-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_2) && (move.endAngle > move.startAngle) {
-												// The angle spans all 4 axes
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-											}
-										} else if inQuadrant(move.startAngle, QUADRANT_3) {
-											if inQuadrant(move.endAngle, QUADRANT_2) {
-												// The angle spans the negative x-axis
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_1) {
-												// The angle spans the negative x-axis and positive y-axis
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_4) {
-												// The angle spans the negative x-axis, positive y-axis, and positive x-axis
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_3) && (move.endAngle > move.startAngle) {
-												// The angle spans all 4 axes
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-											}
-										} else if inQuadrant(move.startAngle, QUADRANT_4) {
-											if inQuadrant(move.endAngle, QUADRANT_3) {
-												// The angle spans the negative y-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_2) {
-												// The angle spans the negative y-axis and negative x-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_1) {
-												// The angle spans the negative y-axis, negative x-axis, and positive y-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_4) && (move.endAngle > move.startAngle) {
-												// The angle spans all 4 axes
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-											}
+									} else if inQuadrant(move.startAngle, QUADRANT_3) {
+										if inQuadrant(move.endAngle, QUADRANT_4) {
+											// The angle spans the negative y-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_1) {
+											// The angle spans the negative y-axis and positive x-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_2) {
+											// The angle spans the negative y-axis, positive x-axis, and positive y-axis
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_3) && (move.endAngle < move.startAngle) {
+											// The angle spans all 4 axes
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
 										}
-									} else {
-										if inQuadrant(move.startAngle, QUADRANT_1) {
-											if inQuadrant(move.endAngle, QUADRANT_2) {
-												// The angle spans the positive y-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_3) {
-												// The angle spans the positive y-axis and negative x-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_4) {
-												// The angle spans the positive y-axis, negative x-axis, and negative y-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_1) && (move.endAngle < move.startAngle) {
-												// The angle spans all 4 axes
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-											}
-										} else if inQuadrant(move.startAngle, QUADRANT_2) {
-											if inQuadrant(move.endAngle, QUADRANT_3) {
-												// The angle spans the negative x-axis
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_4) {
-												// The angle spans the negative x-axis and negative y-axis
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_1) {
-												// The angle spans the negative x-axis, negative y-axis, and positive x-axis
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_2) && (move.endAngle < move.startAngle) {
-												// The angle spans all 4 axes
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-											}
-										} else if inQuadrant(move.startAngle, QUADRANT_3) {
-											if inQuadrant(move.endAngle, QUADRANT_4) {
-												// The angle spans the negative y-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_1) {
-												// The angle spans the negative y-axis and positive x-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_2) {
-												// The angle spans the negative y-axis, positive x-axis, and positive y-axis
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_3) && (move.endAngle < move.startAngle) {
-												// The angle spans all 4 axes
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-											}
-										} else if inQuadrant(move.startAngle, QUADRANT_4) {
-											if inQuadrant(move.endAngle, QUADRANT_1) {
-												// The angle spans the positive x-axis
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_2) {
-												// The angle spans the positive x-axis and positive y-axis
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_3) {
-												// The angle spans the positive x-axis, positive y-axis, and negative x-axis
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-											} else if inQuadrant(move.endAngle, QUADRANT_4) && (move.endAngle < move.startAngle) {
-												// The angle spans all 4 axes
-												bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
-												bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
-											}
+									} else if inQuadrant(move.startAngle, QUADRANT_4) {
+										if inQuadrant(move.endAngle, QUADRANT_1) {
+											// The angle spans the positive x-axis
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_2) {
+											// The angle spans the positive x-axis and positive y-axis
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_3) {
+											// The angle spans the positive x-axis, positive y-axis, and negative x-axis
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+										} else if inQuadrant(move.endAngle, QUADRANT_4) && (move.endAngle < move.startAngle) {
+											// The angle spans all 4 axes
+											bounds.updateBoundsAperture(move.centerX+radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY+radius, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX-radius, move.centerY, apertureMinSize)
+											bounds.updateBoundsAperture(move.centerX, move.centerY-radius, apertureMinSize)
 										}
 									}
 								}
 							}
-
-							// Finally, update the graphics state with the new end coordinate
-							gfxState.updateCurrentCoordinate(move.newX, move.newY)
 						}
+
+						// Finally, update the graphics state with the new end coordinate
+						gfxState.updateCurrentCoordinate(move.newX, move.newY)
 					}
 				}
 
 			case MOVE_OPERATION, FLASH_OPERATION:
-				// For bounds checking, we treat moves and flashes the same
+				// For bounds checking, we treat moves and flashes the same.  The exception is a move made before any aperture
+				// has been selected, which the spec allows (files made only of regions never select one), and which can't be
+				// given a size, so it just moves the current point
+				if !gfxState.apertureSet && interpolation.opCode == MOVE_OPERATION {
+					gfxState.updateCurrentCoordinate(move.newX, move.newY)
+					break
+				}
 				if !gfxState.apertureSet {
 					return fmt.Errorf("Attempt to check interpolation bounds before aperture set")
 				}
@@ -564,7 +332,7 @@ func (interpolation *Interpolation) ProcessDataBlockBoundsCheck(bounds *ImageBou
 	return nil
 }
 
-func (interpolation *Interpolation) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (interpolation *Interpolation) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
 	// First, if this interpolation has a valid function code, update the graphics state
 	if interpolation.fnCodeValid {
 		switch interpolation.fnCode {
@@ -574,216 +342,31 @@ func (interpolation *Interpolation) ProcessDataBlockSurface(surface *cairo.Surfa
 		}
 	}
 
-	// Next, if this interpolation has a valid operation code, perform the operation
+	// Next, if this interpolation has a valid operation code, record the object it creates
 	if interpolation.opCodeValid {
-		if gfxState.regionModeOn {
-			return interpolation.performDrawRegionOn(surface, gfxState)
+		if move, err := interpolation.getNewCoordinate(gfxState); err != nil {
+			return err
 		} else {
-			return interpolation.performDrawRegionOff(surface, gfxState)
-		}
-	}
+			switch {
+			case interpolation.opCode == FLASH_OPERATION && gfxState.regionModeOn:
+				return fmt.Errorf("Flash operations are not allowed while in region mode")
 
-	return nil
-}
-
-func (interpolation *Interpolation) performDrawRegionOff(surface *cairo.Surface, gfxState *GraphicsState) error {
-	if move, err := interpolation.getNewCoordinate(gfxState); err != nil {
-		return err
-	} else {
-		switch interpolation.opCode {
-		case INTERPOLATE_OPERATION:
-			if !gfxState.apertureSet {
-				return fmt.Errorf("Attempt to draw before aperture set")
-			}
-
-			if aperture, found := gfxState.apertures[gfxState.currentAperture]; !found {
-				return fmt.Errorf("Attempt to use aperture %d before it has been defined", gfxState.currentAperture)
-			} else {
-				//apertureMinSize := aperture.GetMinSize(gfxState)
-
-				switch gfxState.currentInterpolationMode {
-				case LINEAR_INTERPOLATION:
-					/*
-						lineAngle := math.Atan2(newY - gfxState.currentY, newX - gfxState.currentX)
-						lineLength := math.Hypot(newX - gfxState.currentX, newY - gfxState.currentY)
-						totalSteps := lineLength / apertureMinSize
-						xDrawStep := apertureMinSize * math.Cos(lineAngle)
-						yDrawStep := apertureMinSize * math.Sin(lineAngle)
-
-						for x,y,step := gfxState.currentX,gfxState.currentY,0.0; step < totalSteps; x,y,step = x + xDrawStep,y + yDrawStep,step + 1.0 {
-							if err := aperture.DrawApertureSurface(surface, gfxState, x, y); err != nil {
-								return err
-							}
-						}
-
-						// Make sure we draw the aperture at the actual end coordinate.
-						// NOTE: This is probably redundant, but because of how I'm optimizing the
-						// coordinate stepping, it's possible that we won't exactly hit the end,
-						// so we do it here again just in case
-						if err := aperture.DrawApertureSurface(surface, gfxState, newX, newY); err != nil {
-							return err
-						}
-					*/
-					aperture.StrokeApertureLinear(surface, gfxState, gfxState.currentX, gfxState.currentY, move.newX, move.newY)
-
-					// Finally, update the graphics state with the new end coordinate
-					gfxState.updateCurrentCoordinate(move.newX, move.newY)
-
-				case CIRCULAR_INTERPOLATION_CLOCKWISE:
-					if gfxState.currentQuadrantMode == MULTI_QUADRANT_MODE {
-						// If we're in multi-quadrant mode, we may need to adjust the end angle by 2 pi so that the angle spans come out right
-						if epsilonEquals(move.startAngle, move.endAngle, gfxState.filePrecision) {
-							// If the angles are equal, we're drawing a full circle, so process the end angle by 2 pi in the proper direction
-							move.endAngle -= TWO_PI
-						} else if move.endAngle > move.startAngle {
-							// If the end angle is greater than the start angle, we process the end angle by 2 pi in the proper direction
-							move.endAngle += TWO_PI
-						}
-					}
-					/*
-						radius := math.Hypot(newX - centerX, newY - centerY)
-						angleStep := apertureMinSize / radius
-
-						for angle := angle1; angle > angle2; angle -= angleStep {
-							offsetX := radius * math.Cos(angle)
-							offsetY := radius * math.Sin(angle)
-							if err := aperture.DrawApertureSurface(surface, gfxState, centerX + offsetX, centerY + offsetY); err != nil {
-								return err
-							}
-						}
-
-						// Make sure we draw the aperture at the actual end coordinate.
-						// NOTE: This is probably redundant, but because of how I'm optimizing the
-						// coordinate stepping, it's possible that we won't exactly hit the end,
-						// so we do it here again just in case
-						if err := aperture.DrawApertureSurface(surface, gfxState, newX, newY); err != nil {
-							return err
-						}
-					*/
-					radius := math.Hypot(move.newX-move.centerX, move.newY-move.centerY)
-					aperture.StrokeApertureClockwise(surface, gfxState, move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
-
-					// Finally, update the graphics state with the new end coordinate
-					gfxState.updateCurrentCoordinate(move.newX, move.newY)
-
-				case CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
-					if gfxState.currentQuadrantMode == MULTI_QUADRANT_MODE {
-						// If we're in multi-quadrant mode, we may need to adjust the end angle by 2 pi so that the angle spans come out right
-						if epsilonEquals(move.startAngle, move.endAngle, gfxState.filePrecision) {
-							// If the angles are equal, we're drawing a full circle, so process the end angle by 2 pi in the proper direction
-							move.endAngle += TWO_PI
-						} else if move.startAngle > move.endAngle {
-							// If the end angle is greater than the start angle, we process the end angle by 2 pi in the proper direction
-							move.endAngle += TWO_PI
-						}
-					}
-					/*
-						radius := math.Hypot(newX - centerX, newY - centerY)
-						angleStep := apertureMinSize / radius
-
-						for angle := angle1; angle < angle2; angle += angleStep {
-							offsetX := radius * math.Cos(angle)
-							offsetY := radius * math.Sin(angle)
-							if err := aperture.DrawApertureSurface(surface, gfxState, centerX + offsetX, centerY + offsetY); err != nil {
-								return err
-							}
-						}
-
-						// Make sure we draw the aperture at the actual end coordinate.
-						// NOTE: This is probably redundant, but because of how I'm optimizing theaperture.DrawApertureSurfaceNoHole(surface, gfxState, centerX, centerY)
-						// coordinate stepping, it's possible that we won't exactly hit the end,
-						// so we do it here again just in case
-						if err := aperture.DrawApertureSurface(surface, gfxState, newX, newY); err != nil {
-							return err
-						}
-					*/
-					radius := math.Hypot(move.newX-move.centerX, move.newY-move.centerY)
-					aperture.StrokeApertureCounterClockwise(surface, gfxState, move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
-
-					// Finally, update the graphics state with the new end coordinate
-					gfxState.updateCurrentCoordinate(move.newX, move.newY)
-				}
-			}
-
-		case MOVE_OPERATION:
-			gfxState.updateCurrentCoordinate(move.newX, move.newY)
-
-		case FLASH_OPERATION:
-			if !gfxState.apertureSet {
-				return fmt.Errorf("Attempt to draw before aperture set")
-			}
-
-			if aperture, found := gfxState.apertures[gfxState.currentAperture]; !found {
-				return fmt.Errorf("Attempt to use aperture %d before it has been defined", gfxState.currentAperture)
-			} else {
-				gfxState.updateCurrentCoordinate(move.newX, move.newY)
-				return aperture.DrawApertureSurface(surface, gfxState, gfxState.currentX, gfxState.currentY)
-			}
-		}
-
-		return nil
-	}
-}
-
-func (interpolation *Interpolation) performDrawRegionOn(surface *cairo.Surface, gfxState *GraphicsState) error {
-	if move, err := interpolation.getNewCoordinate(gfxState); err != nil {
-		return err
-	} else {
-		switch interpolation.opCode {
-		case INTERPOLATE_OPERATION:
-			// Add the new segment to the current surface path
-			switch gfxState.currentInterpolationMode {
-			case LINEAR_INTERPOLATION:
-				surface.LineTo(move.newX, move.newY)
-
-			case CIRCULAR_INTERPOLATION_CLOCKWISE:
-				radius := math.Hypot(gfxState.currentX-move.centerX, gfxState.currentY-move.centerY)
-				if epsilonEquals(move.startAngle, move.endAngle, gfxState.filePrecision) && (gfxState.currentQuadrantMode == MULTI_QUADRANT_MODE) {
-					// NOTE: Special case, if the angles are equal, and we're in multi quadrant mode, we're drawing a full circle
-					// TODO: This feels hacky, see if I can come up with a better way to handle this
-					move.endAngle -= TWO_PI
+			case interpolation.opCode == FLASH_OPERATION, interpolation.opCode == INTERPOLATE_OPERATION && !gfxState.regionModeOn:
+				// Region contours aren't stroked with an aperture, but everything else needs one
+				if !gfxState.apertureSet {
+					return fmt.Errorf("Attempt to draw before aperture set")
 				}
-
-				// NOTE: The arc direction is relative to the gerber file coordinate frame
-				// The conversion to the cairo coordinate frame is inherent in the y-axis mirror transformation of the surface
-				surface.ArcNegative(move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
-
-			case CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
-				radius := math.Hypot(gfxState.currentX-move.centerX, gfxState.currentY-move.centerY)
-				if epsilonEquals(move.startAngle, move.endAngle, gfxState.filePrecision) && (gfxState.currentQuadrantMode == MULTI_QUADRANT_MODE) {
-					// NOTE: Special case, if the angles are equal, and we're in multi quadrant mode, we're drawing a full circle
-					// TODO: This feels hacky, see if I can come up with a better way to handle this
-					move.endAngle += TWO_PI
+				if _, found := gfxState.apertures[gfxState.currentAperture]; !found {
+					return fmt.Errorf("Attempt to use aperture %d before it has been defined", gfxState.currentAperture)
 				}
-
-				// NOTE: The arc direction is relative to the gerber file coordinate frame
-				// The conversion to the cairo coordinate frame is inherent in the y-axis mirror transformation of the surface
-				surface.Arc(move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
-			}
-
-			gfxState.updateCurrentCoordinate(move.newX, move.newY)
-
-		case MOVE_OPERATION:
-			// If we're in region mode, this means we're closing off a contour.  First, set the proper polarity,
-			// then perform the actual draw
-			switch gfxState.currentLevelPolarity {
-			case DARK_POLARITY:
-				surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-
-			case CLEAR_POLARITY:
-				surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
 			}
-			surface.Fill()
 
-			// Now, update the current point
+			gfxState.recordGraphicsObject(interpolation.opCode, move)
 			gfxState.updateCurrentCoordinate(move.newX, move.newY)
-
-		case FLASH_OPERATION:
-			return fmt.Errorf("Flash operations are not allowed while in region mode")
 		}
-
-		return nil
 	}
+
+	return nil
 }
 
 type InterpolationMove struct {
@@ -802,7 +385,7 @@ func (interpolation *Interpolation) getNewCoordinate(gfxState *GraphicsState) (*
 	if interpolation.xValid {
 		switch gfxState.coordinateNotation {
 		case ABSOLUTE_NOTATION:
-			newMove.newX = interpolation.x
+			newMove.newX = interpolation.x + gfxState.stepOffsetX
 
 		case INCREMENTAL_NOTATION:
 			newMove.newX = gfxState.currentX + interpolation.x
@@ -814,7 +397,7 @@ func (interpolation *Interpolation) getNewCoordinate(gfxState *GraphicsState) (*
 	if interpolation.yValid {
 		switch gfxState.coordinateNotation {
 		case ABSOLUTE_NOTATION:
-			newMove.newY = interpolation.y
+			newMove.newY = interpolation.y + gfxState.stepOffsetY
 
 		case INCREMENTAL_NOTATION:
 			newMove.newY = gfxState.currentY + interpolation.y
@@ -823,6 +406,11 @@ func (interpolation *Interpolation) getNewCoordinate(gfxState *GraphicsState) (*
 		newMove.newY = gfxState.currentY
 	}
 
+	// Moves and flashes only go to the new point, so there's no arc to work out, even if a circular interpolation mode is set
+	if interpolation.opCode != INTERPOLATE_OPERATION {
+		return newMove, nil
+	}
+
 	switch gfxState.currentInterpolationMode {
 	case LINEAR_INTERPOLATION:
 		// If this is a linear interpolation, we're done (we only need to calculate center and angle for circular interpolations)
@@ -852,7 +440,6 @@ func (interpolation *Interpolation) getNewCoordinate(gfxState *GraphicsState) (*
 
 					// Now, make sure the the candidate center produces an arc with the correct direction that is <= 90 degrees
 					// NOTE: All of the comparisons are done in the gerber-file coordinate frame
-					// The conversion to the cairo coordinate frame is inherent in the y-axis mirror transformation of the surface
 					switch gfxState.currentInterpolationMode {
 					case CIRCULAR_INTERPOLATION_CLOCKWISE:
 						if (startAngle >= endAngle) && ((startAngle - endAngle) <= ONE_HALF_PI) {
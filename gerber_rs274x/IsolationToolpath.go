@@ -0,0 +1,119 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// MillCAM holds the settings for milling isolation cuts around the copper of a layer.  Coordinates are in millimetres
+// (with the image parameters applied) before they go through TranslateScale
+type MillCAM struct {
+	Wrt            io.WriteCloser
+	SafeZ          float64
+	CutZ           float64
+	PlungeF        int
+	CutF           int
+	ToolDiameter   float64
+	SpindleSpeed   int // In RPM, DEFAULT_SPINDLE_SPEED when zero
+	Passes         int
+	Overlap        float64 // The fraction of the tool diameter each pass overlaps the one before it, from 0 up to (but not including) 1
+	TranslateScale func(float64, float64) (float64, float64)
+}
+
+// GenerateIsolation mills around the copper of a layer, so it is left standing in islands.  The first pass runs with the
+// edge of the tool just touching the copper, and each further pass moves out by the tool diameter less the overlap,
+// clearing a wider channel.  Each pass follows every outline and hole of the grown copper, and the contours are milled
+// nearest first to keep the rapid moves between them short
+func GenerateIsolation(cam *MillCAM, parsedFile []DataBlock) error {
+	if cam.ToolDiameter <= 0.0 {
+		return fmt.Errorf("Tool diameter must be positive, got %f", cam.ToolDiameter)
+	}
+	if cam.Passes < 1 {
+		return fmt.Errorf("At least one isolation pass is needed, got %d", cam.Passes)
+	}
+	if cam.Overlap < 0.0 || cam.Overlap >= 1.0 {
+		return fmt.Errorf("Pass overlap must be at least 0 and less than 1, got %f", cam.Overlap)
+	}
+
+	image, err := InterpretGerberFile(parsedFile)
+	if err != nil {
+		return err
+	}
+
+	// A fiftieth of the tool is well below what the tool can cut
+	tolerance := cam.ToolDiameter / 50.0
+	copper, err := image.Flatten(tolerance)
+	if err != nil {
+		return err
+	}
+
+	toolRadius := cam.ToolDiameter / 2.0
+	stepOver := cam.ToolDiameter * (1.0 - cam.Overlap)
+
+	contours := make([]Polygon, 0, 10)
+	for pass := 0; pass < cam.Passes; pass++ {
+		offset, err := OffsetPolygons(copper, toolRadius+(float64(pass)*stepOver), tolerance)
+		if err != nil {
+			return err
+		}
+		contours = append(contours, getPolygonsWithHolesContours(offset)...)
+	}
+
+	w := cam.Wrt
+	fmt.Fprintf(w, "; My MillCAM\n")
+	fmt.Fprintf(w, "G90G40G17G21\n")
+	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+	fmt.Fprintf(w, "M3S%d\n", getSpindleSpeed(cam.SpindleSpeed))
+
+	for _, contour := range orderContours(contours, Point{}) {
+		x, y := cam.TranslateScale(contour[0].X, contour[0].Y)
+		fmt.Fprintf(w, "G00X%fY%f\n", x, y)
+		fmt.Fprintf(w, "G01Z%fF%d\n", cam.CutZ, cam.PlungeF)
+		for i := 1; i <= len(contour); i++ {
+			// Finish back where the contour started, to close it
+			x, y = cam.TranslateScale(contour[i%len(contour)].X, contour[i%len(contour)].Y)
+			fmt.Fprintf(w, "G01X%fY%fF%d\n", x, y, cam.CutF)
+		}
+		fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+	}
+
+	fmt.Fprintf(w, "M5\n")
+
+	return nil
+}
+
+// orderContours sorts closed contours into a short milling order, by repeatedly going to the nearest vertex of any contour
+// not milled yet.  Each contour is rotated to start at the vertex it was reached at
+func orderContours(contours []Polygon, start Point) []Polygon {
+	remaining := make([]Polygon, 0, len(contours))
+	for _, contour := range contours {
+		if len(contour) > 0 {
+			remaining = append(remaining, contour)
+		}
+	}
+
+	ordered := make([]Polygon, 0, len(remaining))
+	current := start
+	for len(remaining) > 0 {
+		bestContour, bestVertex := 0, 0
+		bestDistance := math.MaxFloat64
+		for i, contour := range remaining {
+			for j, point := range contour {
+				if distance := math.Hypot(point.X-current.X, point.Y-current.Y); distance < bestDistance {
+					bestContour, bestVertex, bestDistance = i, j, distance
+				}
+			}
+		}
+
+		contour := remaining[bestContour]
+		rotated := append(append(make(Polygon, 0, len(contour)), contour[bestVertex:]...), contour[:bestVertex]...)
+		ordered = append(ordered, rotated)
+		current = rotated[0]
+
+		remaining[bestContour] = remaining[len(remaining)-1]
+		remaining = remaining[:len(remaining)-1]
+	}
+
+	return ordered
+}
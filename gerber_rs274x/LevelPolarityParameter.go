@@ -2,16 +2,10 @@ package gerber_rs274x
 
 import (
 	"fmt"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
-func (levelPolarity *LevelPolarityParameter) ProcessDataBlockToolpath(camo *CamOutput, gfxState *GraphicsState) error {
-	levelPolarity.ProcessDataBlockSurface(nil, gfxState)
-	return nil
-}
-
 type LevelPolarityParameter struct {
+	dataBlockPosition
 	paramCode ParameterCode
 	polarity  Polarity
 }
@@ -21,15 +15,13 @@ func (levelPolarity *LevelPolarityParameter) DataBlockPlaceholder() {
 }
 
 func (levelPolarity *LevelPolarityParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
-	gfxState.currentLevelPolarity = levelPolarity.polarity
+	gfxState.currentLevelPolarity = gfxState.imageParams.effectivePolarity(levelPolarity.polarity)
 
 	return nil
 }
 
-func (levelPolarity *LevelPolarityParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	gfxState.currentLevelPolarity = levelPolarity.polarity
-
-	return nil
+func (levelPolarity *LevelPolarityParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return levelPolarity.ProcessDataBlockBoundsCheck(nil, gfxState)
 }
 
 func (lpParam *LevelPolarityParameter) String() string {
@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type LowerLeftLinePrimitive struct {
@@ -27,9 +26,12 @@ func (primitive *LowerLeftLinePrimitive) GetPrimitiveBounds(env *ExpressionEnvir
 	return 0.0, 0.0, 0.0, 0.0
 }
 
-func (primitive *LowerLeftLinePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
-	return nil
+func (primitive *LowerLeftLinePrimitive) getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error) {
+	lowerLeftX := primitive.lowerLeftX.EvaluateExpression(env)
+	lowerLeftY := primitive.lowerLeftY.EvaluateExpression(env)
+	polygon := rectanglePolygon(lowerLeftX, lowerLeftX+primitive.width.EvaluateExpression(env), lowerLeftY, lowerLeftY+primitive.height.EvaluateExpression(env))
+
+	return newPrimitiveLayer([]Polygon{polygon}, primitive.exposure.EvaluateExpression(env), primitive.rotationAngle.EvaluateExpression(env)), nil
 }
 
 func (primitive *LowerLeftLinePrimitive) String() string {
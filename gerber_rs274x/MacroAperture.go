@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 	"math"
 )
 
@@ -69,102 +68,11 @@ func (aperture *MacroAperture) DrawApertureBoundsCheck(bounds *ImageBounds, gfxS
 	return nil
 }
 
-func (aperture *MacroAperture) DrawApertureSurface(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-	radiusX := (aperture.xMax - aperture.xMin) / 2.0
-	radiusY := (aperture.yMax - aperture.yMin) / 2.0
-
-	correctedX := x - radiusX
-	correctedY := y - radiusY
-
-	return renderApertureToSurface(aperture, surface, gfxState, correctedX, correctedY)
-}
-
-func (aperture *MacroAperture) DrawApertureSurfaceNoHole(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-	radiusX := (aperture.xMax - aperture.xMin) / 2.0
-	radiusY := (aperture.yMax - aperture.yMin) / 2.0
-
-	correctedX := x - radiusX
-	correctedY := y - radiusY
-
-	return renderApertureNoHoleToSurface(aperture, surface, gfxState, correctedX, correctedY)
-}
-
-func (aperture *MacroAperture) StrokeApertureLinear(surface *cairo.Surface, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
-	return nil
-}
-
-func (aperture *MacroAperture) StrokeApertureClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
-	return nil
-}
-
-func (aperture *MacroAperture) StrokeApertureCounterClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
-	return nil
-}
-
-func (aperture *MacroAperture) renderApertureToGraphicsState(gfxState *GraphicsState) {
-	// This will render the aperture to a cairo surface the first time it is needed, then
-	// cache it in the graphics state.  Subsequent draws of the aperture will used the cached surface
-
-	rangeX := aperture.xMax - aperture.xMin
-	rangeY := aperture.yMax - aperture.yMin
-	radiusX := rangeX / 2.0
-	radiusY := rangeY / 2.0
-
-	// Construct the surface we're drawing to
-	imageSizeX := int(math.Ceil(rangeX * gfxState.scaleFactor))
-	imageSizeY := int(math.Ceil(rangeY * gfxState.scaleFactor))
-	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, imageSizeX, imageSizeY)
-	// Scale the surface so we can use unscaled coordinates in the primitive rendering routines
-	surface.Scale(gfxState.scaleFactor, gfxState.scaleFactor)
-	// Apply an offset to the surface, so that the origin is actually in the center of the image
-	surface.Translate(radiusX, radiusY)
-
-	// Set fill rule to Even/Odd so that rings render correctly
-	surface.SetFillRule(cairo.FILL_RULE_EVEN_ODD)
-
-	// Draw the aperture
-	if gfxState.currentLevelPolarity == DARK_POLARITY {
-		surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-	} else {
-		surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
-	}
-
-	// Retrieve the macro from the graphics state
-	if macro, found := gfxState.apertureMacros[aperture.macroName]; !found {
-		//TODO: Figure out the error behavior, just print a warning for now
-		fmt.Printf("Error: Attempt to render macro aperture %s before it has been defined\n", aperture.macroName)
-	} else {
-		for _, dataBlock := range macro {
-			switch dataBlockValue := dataBlock.(type) {
-			case *ApertureMacroComment:
-				//Nothing to do here
-
-			case *ApertureMacroVariableDefinition:
-				// Need to update the expression environment
-				aperture.env.setVariableValue(dataBlockValue.variableNumber, dataBlockValue.value.EvaluateExpression(aperture.env))
-
-			case AperturePrimitive:
-				if err := dataBlockValue.DrawPrimitiveToSurface(surface, aperture.env); err != nil {
-					// TODO: Figure out the error behavior, just print a warning for now
-					fmt.Printf("Error while attempting to render primitive on macro aperture %s: %s\n", aperture.macroName, err.Error())
-				}
-			}
-		}
-	}
-
-	surface.WriteToPNG(fmt.Sprintf("Aperture-%d.png", aperture.apertureNumber))
-
-	gfxState.renderedApertures[aperture.apertureNumber] = surface
-}
-
 func (aperture *MacroAperture) calculateApertureSize(macroDataBlocks []ApertureMacroDataBlock) {
 	// We need to execute the entire macro to calculate the size, and this will pollute the enviroment
 	// for when we want to actually render the aperture, so we need to create a copy of the environment to use
 	// while calculating size
-	sizeEnv := NewExpressionEnvironment()
-	for key, value := range aperture.env.variables {
-		sizeEnv.setVariableValue(key, value)
-	}
+	sizeEnv := aperture.env.copy()
 
 	for _, dataBlock := range macroDataBlocks {
 		switch dataBlockValue := dataBlock.(type) {
@@ -194,6 +102,33 @@ func (aperture *MacroAperture) calculateApertureSize(macroDataBlocks []ApertureM
 	aperture.boundsCalculated = true
 }
 
+func (aperture *MacroAperture) getPolygons(apertureMacro []ApertureMacroDataBlock, tolerance float64) ([]PolygonLayer, error) {
+	if apertureMacro == nil {
+		return nil, fmt.Errorf("Missing definition of aperture macro %s for aperture %d", aperture.macroName, aperture.apertureNumber)
+	}
+
+	// Like calculating the size, executing the macro updates the variables, so work on a copy of the environment
+	polygonEnv := aperture.env.copy()
+
+	// Each primitive becomes its own layer, since primitives with exposure off erase the primitives before them
+	layers := make([]PolygonLayer, 0, len(apertureMacro))
+	for _, dataBlock := range apertureMacro {
+		switch dataBlockValue := dataBlock.(type) {
+		case *ApertureMacroVariableDefinition:
+			polygonEnv.setVariableValue(dataBlockValue.variableNumber, dataBlockValue.value.EvaluateExpression(polygonEnv))
+
+		case AperturePrimitive:
+			if layer, err := dataBlockValue.getPrimitivePolygons(polygonEnv, tolerance); err != nil {
+				return nil, fmt.Errorf("Error converting primitive on macro aperture %s: %s", aperture.macroName, err.Error())
+			} else {
+				layers = append(layers, layer)
+			}
+		}
+	}
+
+	return layers, nil
+}
+
 func (aperture *MacroAperture) String() string {
 	return fmt.Sprintf("{MA, Name: %s}", aperture.macroName)
 }
@@ -2,11 +2,10 @@ package gerber_rs274x
 
 import (
 	"fmt"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type ModeParameter struct {
+	dataBlockPosition
 	paramCode ParameterCode
 	units     Units
 }
@@ -16,18 +15,13 @@ func (mode *ModeParameter) DataBlockPlaceholder() {
 }
 
 func (mode *ModeParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
-	//TODO: For now this doesn't alter the graphics state or draw anything
-	return nil
-}
+	gfxState.units = mode.units
 
-func (mode *ModeParameter) ProcessDataBlockToolpath(*CamOutput, *GraphicsState) error {
-	//TODO: For now this doesn't alter the graphics state or draw anything
 	return nil
 }
 
-func (mode *ModeParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	//TODO: For now this doesn't alter the graphics state or draw anything
-	return nil
+func (mode *ModeParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return mode.ProcessDataBlockBoundsCheck(nil, gfxState)
 }
 
 func (moParam *ModeParameter) String() string {
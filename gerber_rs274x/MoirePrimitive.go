@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 	"math"
 )
 
@@ -36,80 +35,38 @@ func (primitive *MoirePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment)
 	return centerX - maxRadius, centerX + maxRadius, centerY - maxRadius, centerY + maxRadius
 }
 
-func (primitive *MoirePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	// If there is a rotation angle defined, first check that the center is at the origin
-	// (rotations are only allowed if the center is at the origin)
-	centerX := primitive.centerX.EvaluateExpression(env)
-	centerY := primitive.centerY.EvaluateExpression(env)
-	rotation := primitive.rotationAngle.EvaluateExpression(env) * (math.Pi / 180.0)
-
-	if rotation != 0.0 && (centerX != 0.0 || centerY != 0.0) {
-		return fmt.Errorf("Moire primitive rotation is only allowed if the center is at the origin")
-	}
-
-	// Now that we've checked the center, first apply a translation to account for the offset,
-	// then apply the rotation
-	surface.Save()
-	surface.Rotate(rotation)
-
-	surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-
-	// Start drawing the rings
+func (primitive *MoirePrimitive) getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error) {
+	center := Point{primitive.centerX.EvaluateExpression(env), primitive.centerY.EvaluateExpression(env)}
 	maxRings := int(primitive.maxRings.EvaluateExpression(env))
-	radius := (primitive.outerDiameter.EvaluateExpression(env) / 2.0)
+	radius := primitive.outerDiameter.EvaluateExpression(env) / 2.0
 	thickness := primitive.ringThickness.EvaluateExpression(env)
 	gap := primitive.ringGap.EvaluateExpression(env)
+
+	// Each ring is a solid circle with a hole, and the rings are nested inside each other's holes
+	polygons := make([]Polygon, 0, (2*maxRings)+2)
 	for ring := 0; ring < maxRings; ring++ {
 		outerRadius := radius - ((thickness + gap) * float64(ring))
 		innerRadius := outerRadius - thickness
+		if outerRadius <= 0.0 {
+			break
+		}
 
-		// Draw the outer portion of the ring
-		surface.Arc(centerX, centerY, outerRadius, 0.0, TWO_PI)
-
-		if innerRadius > 0.0 {
-			// Draw the inner portion of the ring
-			surface.Arc(centerX, centerY, innerRadius, 0.0, TWO_PI)
-			surface.Fill()
-		} else {
-			// We've reached the center, so fill the surface and break out of the loop
-			surface.Fill()
+		polygons = append(polygons, circlePolygon(center, outerRadius, tolerance))
+		if innerRadius <= 0.0 {
+			// We've reached the center
 			break
 		}
+		polygons = append(polygons, circlePolygon(center, innerRadius, tolerance).Reverse())
 	}
 
-	// Now, draw the crosshair
-	crosshairHalfLength := (primitive.crosshairLength.EvaluateExpression(env) / 2.0)
-	crosshairHalfThickness := (primitive.crosshairThickness.EvaluateExpression(env) / 2.0)
-	horzLeftX := centerX - crosshairHalfLength
-	horzRightX := centerX + crosshairHalfLength
-	horzTopY := centerY + crosshairHalfThickness
-	horzBottomY := centerY - crosshairHalfThickness
-	vertLeftX := centerX - crosshairHalfThickness
-	vertRightX := centerX + crosshairHalfThickness
-	vertTopY := centerY + crosshairHalfLength
-	vertBottomY := centerY - crosshairHalfLength
-	// Horizontal crosshair portion
-	surface.MoveTo(horzLeftX, horzTopY)
-	surface.LineTo(horzRightX, horzTopY)
-	surface.LineTo(horzRightX, horzBottomY)
-	surface.LineTo(horzLeftX, horzBottomY)
-	surface.LineTo(horzLeftX, horzTopY)
-	surface.Fill()
-	// Vertical crosshair portion
-	surface.MoveTo(vertLeftX, vertTopY)
-	surface.LineTo(vertRightX, vertTopY)
-	surface.LineTo(vertRightX, vertBottomY)
-	surface.LineTo(vertLeftX, vertBottomY)
-	surface.LineTo(vertLeftX, vertTopY)
-	surface.Fill()
-
-	fmt.Printf("Horizontal Top Left (%f %f) Bottom Right (%f %f)\n", horzLeftX, horzTopY, horzRightX, horzBottomY)
-	fmt.Printf("Vertical Top Left (%f %f) Bottom Right (%f %f)\n", vertLeftX, vertTopY, vertRightX, vertBottomY)
-
-	// Finally, undo the transformations to the surface
-	surface.Restore()
+	// The crosshair overlaps the rings, which the nonzero fill turns into their union
+	crosshairHalfLength := primitive.crosshairLength.EvaluateExpression(env) / 2.0
+	crosshairHalfThickness := primitive.crosshairThickness.EvaluateExpression(env) / 2.0
+	polygons = append(polygons,
+		rectanglePolygon(center.X-crosshairHalfLength, center.X+crosshairHalfLength, center.Y-crosshairHalfThickness, center.Y+crosshairHalfThickness),
+		rectanglePolygon(center.X-crosshairHalfThickness, center.X+crosshairHalfThickness, center.Y-crosshairHalfLength, center.Y+crosshairHalfLength))
 
-	return nil
+	return newPrimitiveLayer(polygons, 1.0, primitive.rotationAngle.EvaluateExpression(env)), nil
 }
 
 func (primitive *MoirePrimitive) String() string {
@@ -0,0 +1,138 @@
+package gerber_rs274x
+
+import "sort"
+
+// PinReference identifies a component pin, as given by the .P object attribute
+type PinReference struct {
+	Refdes string
+	Pin    string
+}
+
+// ObjectGroups indexes graphics objects by the net (.N), component (.C) and pin (.P) object attributes attached to them.
+// Objects can be gathered from several images (eg. every copper layer of a board), so that nets spanning layers end up
+// in a single group
+type ObjectGroups struct {
+	byNet       map[string][]*GraphicsObject
+	noNet       []*GraphicsObject
+	byComponent map[string][]*GraphicsObject
+	byPin       map[PinReference][]*GraphicsObject
+	netPins     map[string]map[PinReference]bool
+}
+
+func NewObjectGroups(images ...*GerberImage) *ObjectGroups {
+	groups := new(ObjectGroups)
+	groups.byNet = make(map[string][]*GraphicsObject)
+	groups.byComponent = make(map[string][]*GraphicsObject)
+	groups.byPin = make(map[PinReference][]*GraphicsObject)
+	groups.netPins = make(map[string]map[PinReference]bool)
+
+	for _, image := range images {
+		for _, graphicsObject := range image.graphicsObjects {
+			groups.addObject(graphicsObject)
+		}
+	}
+
+	return groups
+}
+
+func (groups *ObjectGroups) addObject(graphicsObject *GraphicsObject) {
+	// An object with no .N attribute isn't on any net, so it's kept apart from the nets rather than grouped under the
+	// empty net name, which is what KiCad writes for unconnected pads.  The spec allows an object to belong to several nets
+	netNames := graphicsObject.objectAttributes[".N"]
+	if len(netNames) == 0 {
+		groups.noNet = append(groups.noNet, graphicsObject)
+	}
+
+	refdes, pin, isPin := graphicsObject.GetPin()
+	pinReference := PinReference{refdes, pin}
+
+	for _, netName := range netNames {
+		groups.byNet[netName] = append(groups.byNet[netName], graphicsObject)
+
+		if isPin {
+			if groups.netPins[netName] == nil {
+				groups.netPins[netName] = make(map[PinReference]bool)
+			}
+			groups.netPins[netName][pinReference] = true
+		}
+	}
+
+	if isPin {
+		groups.byPin[pinReference] = append(groups.byPin[pinReference], graphicsObject)
+	}
+
+	// Pads on copper layers usually only carry a .P attribute, so fall back to its reference designator when there's no .C
+	if component := graphicsObject.GetComponent(); len(component) > 0 {
+		groups.byComponent[component] = append(groups.byComponent[component], graphicsObject)
+	} else if isPin {
+		groups.byComponent[refdes] = append(groups.byComponent[refdes], graphicsObject)
+	}
+}
+
+// GetNetNames returns the names of all nets that have at least one object, in sorted order
+func (groups *ObjectGroups) GetNetNames() []string {
+	return sortedKeys(groups.byNet)
+}
+
+func (groups *ObjectGroups) GetNetObjects(netName string) []*GraphicsObject {
+	return groups.byNet[netName]
+}
+
+// GetNoNetObjects returns the objects that have no .N attribute at all
+func (groups *ObjectGroups) GetNoNetObjects() []*GraphicsObject {
+	return groups.noNet
+}
+
+// GetNetPins returns every component pin that has an object on the given net, sorted by reference designator and pin
+func (groups *ObjectGroups) GetNetPins(netName string) []PinReference {
+	pins := make([]PinReference, 0, len(groups.netPins[netName]))
+	for pinReference := range groups.netPins[netName] {
+		pins = append(pins, pinReference)
+	}
+	sortPinReferences(pins)
+
+	return pins
+}
+
+// GetComponents returns the reference designators of all components that have at least one object, in sorted order
+func (groups *ObjectGroups) GetComponents() []string {
+	return sortedKeys(groups.byComponent)
+}
+
+func (groups *ObjectGroups) GetComponentObjects(refdes string) []*GraphicsObject {
+	return groups.byComponent[refdes]
+}
+
+// GetPins returns every component pin that has at least one object, sorted by reference designator and pin
+func (groups *ObjectGroups) GetPins() []PinReference {
+	pins := make([]PinReference, 0, len(groups.byPin))
+	for pinReference := range groups.byPin {
+		pins = append(pins, pinReference)
+	}
+	sortPinReferences(pins)
+
+	return pins
+}
+
+func (groups *ObjectGroups) GetPinObjects(pinReference PinReference) []*GraphicsObject {
+	return groups.byPin[pinReference]
+}
+
+func sortedKeys(objectMap map[string][]*GraphicsObject) []string {
+	keys := make([]string, 0, len(objectMap))
+	for key := range objectMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortPinReferences(pins []PinReference) {
+	sort.Slice(pins, func(i int, j int) bool {
+		if pins[i].Refdes != pins[j].Refdes {
+			return pins[i].Refdes < pins[j].Refdes
+		}
+		return pins[i].Pin < pins[j].Pin
+	})
+}
@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type OutlinePrimitive struct {
@@ -28,9 +27,19 @@ func (primitive *OutlinePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment
 	return 0.0, 0.0, 0.0, 0.0
 }
 
-func (primitive *OutlinePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
-	return nil
+func (primitive *OutlinePrimitive) getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error) {
+	if len(primitive.subsequentX) < 3 {
+		return PolygonLayer{}, fmt.Errorf("Outline primitive needs at least 3 points after the start point, got %d", len(primitive.subsequentX))
+	}
+
+	// The last point repeats the start point, so it's left off
+	polygon := make(Polygon, 0, len(primitive.subsequentX))
+	polygon = append(polygon, Point{primitive.startX.EvaluateExpression(env), primitive.startY.EvaluateExpression(env)})
+	for i := 0; i < len(primitive.subsequentX)-1; i++ {
+		polygon = append(polygon, Point{primitive.subsequentX[i].EvaluateExpression(env), primitive.subsequentY[i].EvaluateExpression(env)})
+	}
+
+	return newPrimitiveLayer([]Polygon{polygon.counterClockwise()}, primitive.exposure.EvaluateExpression(env), primitive.rotationAngle.EvaluateExpression(env)), nil
 }
 
 func (primitive *OutlinePrimitive) String() string {
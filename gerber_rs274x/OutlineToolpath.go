@@ -0,0 +1,306 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// Outline segments whose ends are closer than this (in millimetres) are joined, unless the OutlineCAM sets its own
+// tolerance.  CAD packages often leave tiny gaps where arcs meet lines, from rounding or from snapping when the outline was drawn
+const DEFAULT_OUTLINE_JOIN_TOLERANCE float64 = 0.1
+
+// OutlineCAM holds the settings for cutting a board out along its Edge_Cuts outline.  Depths are Z coordinates, so CutZ
+// is negative, while PassDepth is how far down each pass goes.  Tabs are bridges of material left standing on every
+// contour to hold the board in place, TabWidth wide and TabHeight tall above the bottom of the cut.  Coordinates are in
+// millimetres (with the image parameters applied) before they go through TranslateScale
+type OutlineCAM struct {
+	Wrt            io.WriteCloser
+	SafeZ          float64
+	CutZ           float64
+	PassDepth      float64
+	PlungeF        int
+	CutF           int
+	ToolDiameter   float64
+	SpindleSpeed   int // In RPM, DEFAULT_SPINDLE_SPEED when zero
+	TabCount       int
+	TabWidth       float64
+	TabHeight      float64
+	JoinTolerance  float64
+	TranslateScale func(float64, float64) (float64, float64)
+}
+
+// GenerateOutline cuts the board out along the closed contours drawn in an outline layer.  The tool runs outside the
+// board's outer edge and inside any cutouts, so the finished board keeps the drawn size.  Cutouts are cut first, while
+// the board is still held all the way round
+func GenerateOutline(cam *OutlineCAM, parsedFile []DataBlock) error {
+	if cam.ToolDiameter <= 0.0 {
+		return fmt.Errorf("Tool diameter must be positive, got %f", cam.ToolDiameter)
+	}
+	if cam.TabCount < 0 {
+		return fmt.Errorf("Tab count can't be negative, got %d", cam.TabCount)
+	}
+
+	image, err := InterpretGerberFile(parsedFile)
+	if err != nil {
+		return err
+	}
+
+	joinTolerance := cam.JoinTolerance
+	if joinTolerance <= 0.0 {
+		joinTolerance = DEFAULT_OUTLINE_JOIN_TOLERANCE
+	}
+	tolerance := cam.ToolDiameter / 50.0
+
+	contours, err := getOutlineContours(image, joinTolerance, tolerance)
+	if err != nil {
+		return err
+	}
+
+	// A contour inside an odd number of others is a cutout, and the rest are outer edges
+	var cutouts, edges []Polygon
+	toolRadius := cam.ToolDiameter / 2.0
+	for i, contour := range contours {
+		isCutout := isOutlineCutout(contours, i)
+
+		distance := toolRadius
+		if isCutout {
+			distance = -toolRadius
+		}
+
+		offset, err := OffsetPolygons([]PolygonWithHoles{{Outline: contour.counterClockwise()}}, distance, tolerance)
+		if err != nil {
+			return err
+		}
+		if len(offset) == 0 {
+			return fmt.Errorf("Cutout near (%f, %f) is too small for a %f tool", contour[0].X, contour[0].Y, cam.ToolDiameter)
+		}
+
+		for _, piece := range offset {
+			if isCutout {
+				cutouts = append(cutouts, piece.Outline)
+			} else {
+				edges = append(edges, piece.Outline)
+			}
+		}
+	}
+
+	w := cam.Wrt
+	fmt.Fprintf(w, "; My OutlineCAM\n")
+	fmt.Fprintf(w, "G90G40G17G21\n")
+	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+	fmt.Fprintf(w, "M3S%d\n", getSpindleSpeed(cam.SpindleSpeed))
+
+	ordered := orderContours(cutouts, Point{})
+	if len(ordered) > 0 {
+		ordered = append(ordered, orderContours(edges, ordered[len(ordered)-1][0])...)
+	} else {
+		ordered = orderContours(edges, Point{})
+	}
+
+	for _, contour := range ordered {
+		if err := cam.cutContour(contour); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "M5\n")
+
+	return nil
+}
+
+// cutContour cuts round a closed contour in passes, going down by the pass depth each time, and lifting over the tabs once
+// the cut gets below their tops
+func (cam *OutlineCAM) cutContour(contour Polygon) error {
+	w := cam.Wrt
+
+	// Tabs are spread evenly round the contour, centered between the start and the end of each stretch, so the contour
+	// never starts on a tab.  The tool has to stay clear of the tab on both sides, so it lifts for the tab's width plus
+	// its own diameter
+	length := 0.0
+	for i := range contour {
+		next := contour[(i+1)%len(contour)]
+		length += math.Hypot(next.X-contour[i].X, next.Y-contour[i].Y)
+	}
+
+	var tabs [][2]float64
+	if cam.TabCount > 0 {
+		spacing := length / float64(cam.TabCount)
+		liftLength := cam.TabWidth + cam.ToolDiameter
+		if liftLength >= spacing {
+			return fmt.Errorf("%d tabs %f wide don't fit round a contour %f long", cam.TabCount, cam.TabWidth, length)
+		}
+
+		for i := 0; i < cam.TabCount; i++ {
+			center := (float64(i) + 0.5) * spacing
+			tabs = append(tabs, [2]float64{center - (liftLength / 2.0), center + (liftLength / 2.0)})
+		}
+	}
+	tabTopZ := cam.CutZ + cam.TabHeight
+
+	// Split the contour at the ends of the tabs, so each piece of it is either all on a tab or all off them
+	type pathPoint struct {
+		point    Point
+		distance float64
+	}
+
+	breaks := make([]float64, 0, 2*len(tabs))
+	for _, tab := range tabs {
+		breaks = append(breaks, tab[0], tab[1])
+	}
+
+	path := []pathPoint{{contour[0], 0.0}}
+	distance := 0.0
+	for i := range contour {
+		start, end := contour[i], contour[(i+1)%len(contour)]
+		segmentLength := math.Hypot(end.X-start.X, end.Y-start.Y)
+		for len(breaks) > 0 && breaks[0] < distance+segmentLength {
+			t := (breaks[0] - distance) / segmentLength
+			path = append(path, pathPoint{Point{start.X + (t * (end.X - start.X)), start.Y + (t * (end.Y - start.Y))}, breaks[0]})
+			breaks = breaks[1:]
+		}
+		distance += segmentLength
+		path = append(path, pathPoint{end, distance})
+	}
+
+	onTab := func(distance float64) bool {
+		for _, tab := range tabs {
+			if distance > tab[0] && distance < tab[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	x, y := cam.TranslateScale(contour[0].X, contour[0].Y)
+	fmt.Fprintf(w, "G00X%fY%f\n", x, y)
+
+	for _, passZ := range getPassDepths(cam.CutZ, cam.PassDepth) {
+		currentZ := passZ
+		fmt.Fprintf(w, "G01Z%fF%d\n", passZ, cam.PlungeF)
+
+		for i := 1; i < len(path); i++ {
+			targetZ := passZ
+			if onTab((path[i-1].distance+path[i].distance)/2.0) && passZ < tabTopZ {
+				targetZ = tabTopZ
+			}
+			if targetZ != currentZ {
+				fmt.Fprintf(w, "G01Z%fF%d\n", targetZ, cam.PlungeF)
+				currentZ = targetZ
+			}
+
+			x, y = cam.TranslateScale(path[i].point.X, path[i].point.Y)
+			fmt.Fprintf(w, "G01X%fY%fF%d\n", x, y, cam.CutF)
+		}
+	}
+
+	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+
+	return nil
+}
+
+// getPassDepths lists the depth of each pass, stepping down from the surface until the final cut depth is reached.  With
+// no pass depth, the whole depth is cut in one pass
+func getPassDepths(cutZ float64, passDepth float64) []float64 {
+	if passDepth <= 0.0 {
+		return []float64{cutZ}
+	}
+
+	depths := make([]float64, 0, 10)
+	for z := -passDepth; ; z -= passDepth {
+		if z <= cutZ+1e-9 {
+			return append(depths, cutZ)
+		}
+		depths = append(depths, z)
+	}
+}
+
+// getOutlineContours joins the draws and arcs of an outline layer into closed contours.  Segments are joined end to end
+// whichever way round they were drawn, as long as their ends are within the join tolerance.  Arcs are approximated by
+// chords that never stray more than the chord tolerance from the true curve
+func getOutlineContours(image *GerberImage, joinTolerance float64, tolerance float64) ([]Polygon, error) {
+	segments := make([][]Point, 0, len(image.graphicsObjects))
+	for _, graphicsObject := range image.graphicsObjects {
+		var segment []Point
+		switch graphicsObject.objectType {
+		case DRAW_OBJECT:
+			segment = []Point{graphicsObject.start, graphicsObject.end}
+
+		case ARC_OBJECT:
+			center := graphicsObject.center
+			radius := math.Hypot(graphicsObject.start.X-center.X, graphicsObject.start.Y-center.Y)
+			startAngle := math.Atan2(graphicsObject.start.Y-center.Y, graphicsObject.start.X-center.X)
+			segment = arcPoints(center, radius, startAngle, graphicsObject.sweepAngle, tolerance)
+			segment[len(segment)-1] = graphicsObject.end
+
+		default:
+			// Flashes and regions don't describe an outline
+			continue
+		}
+
+		// Drop dots, which have no direction to follow
+		if segmentLength := math.Hypot(segment[len(segment)-1].X-segment[0].X, segment[len(segment)-1].Y-segment[0].Y); segmentLength > joinTolerance || len(segment) > 2 {
+			segments = append(segments, segment)
+		}
+	}
+
+	isNear := func(a Point, b Point) bool {
+		return math.Hypot(a.X-b.X, a.Y-b.Y) <= joinTolerance
+	}
+
+	used := make([]bool, len(segments))
+	contours := make([]Polygon, 0, 10)
+	for i := range segments {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		chain := append([]Point{}, segments[i]...)
+
+		for !(len(chain) > 2 && isNear(chain[len(chain)-1], chain[0])) {
+			end := chain[len(chain)-1]
+			found := false
+			for j, segment := range segments {
+				if used[j] {
+					continue
+				}
+
+				if isNear(segment[0], end) {
+					chain = append(chain, segment[1:]...)
+				} else if isNear(segment[len(segment)-1], end) {
+					for k := len(segment) - 2; k >= 0; k-- {
+						chain = append(chain, segment[k])
+					}
+				} else {
+					continue
+				}
+
+				used[j] = true
+				found = true
+				break
+			}
+
+			if !found {
+				return nil, fmt.Errorf("Outline isn't closed, it has a loose end at (%f, %f)", end.X, end.Y)
+			}
+		}
+
+		// The last point closes the contour back onto the first
+		contours = append(contours, Polygon(chain[:len(chain)-1]))
+	}
+
+	return contours, nil
+}
+
+// isOutlineCutout reports whether a contour is inside an odd number of the other contours, which makes it a hole cut out
+// of the board rather than an outer edge
+func isOutlineCutout(contours []Polygon, index int) bool {
+	depth := 0
+	for i, other := range contours {
+		if i != index && other.containsPoint(contours[index][0]) {
+			depth++
+		}
+	}
+
+	return depth%2 == 1
+}
@@ -0,0 +1,172 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ParseErrorCode int
+
+const (
+	UNTERMINATED_BLOCK_ERROR ParseErrorCode = iota
+	BLOCK_TOO_LONG_ERROR
+	UNKNOWN_PARAMETER_ERROR
+	INVALID_PARAMETER_ERROR
+	DUPLICATE_PARAMETER_ERROR
+	DUPLICATE_APERTURE_ERROR
+	UNKNOWN_FUNCTION_CODE_ERROR
+	INVALID_DATA_BLOCK_ERROR
+	MISSING_FORMAT_ERROR
+	DEPRECATED_CODE_ERROR
+	DEPRECATED_PARAMETER_ERROR
+	MISSING_D_CODE_ERROR
+	MISSING_END_OF_FILE_ERROR
+)
+
+type ParseSeverity int
+
+const (
+	ERROR_SEVERITY ParseSeverity = iota
+	WARNING_SEVERITY
+)
+
+// SourcePosition locates a block in the gerber file it was parsed from.  Line and column are 1-based, to match what
+// text editors display, while the offset is the 0-based byte offset of the start of the block from the start of the file
+type SourcePosition struct {
+	Line   int
+	Column int
+	Offset int64
+}
+
+func (position SourcePosition) String() string {
+	return fmt.Sprintf("line %d, column %d", position.Line, position.Column)
+}
+
+// dataBlockPosition is embedded in every data block type, so that each parsed data block remembers where it came from
+type dataBlockPosition struct {
+	position SourcePosition
+}
+
+func (blockPosition *dataBlockPosition) GetPosition() SourcePosition {
+	return blockPosition.position
+}
+
+func (blockPosition *dataBlockPosition) setPosition(position SourcePosition) {
+	blockPosition.position = position
+}
+
+type ParseError struct {
+	Code     ParseErrorCode
+	Severity ParseSeverity
+	Position SourcePosition
+	Raw      string
+	Message  string
+}
+
+// ParseErrorList collects every problem found while parsing a file, in the order they were encountered
+type ParseErrorList []*ParseError
+
+func newParseError(code ParseErrorCode, format string, args ...interface{}) *ParseError {
+	return &ParseError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// locateParseError attaches the position and raw text of the offending block to an error returned by one of the
+// block parsing routines.  Errors that weren't already classified by the parsing routine get the supplied default code
+func locateParseError(err error, defaultCode ParseErrorCode, block *LexedBlock) *ParseError {
+	parseError, isParseError := err.(*ParseError)
+	if !isParseError {
+		parseError = &ParseError{Code: defaultCode, Message: err.Error()}
+	}
+
+	parseError.Position = block.position
+	parseError.Raw = block.text
+
+	return parseError
+}
+
+func (parseError *ParseError) Error() string {
+	if parseError.Severity == WARNING_SEVERITY {
+		return fmt.Sprintf("%s: Warning: %s (block %q)", parseError.Position, parseError.Message, parseError.Raw)
+	}
+
+	return fmt.Sprintf("%s: %s (block %q)", parseError.Position, parseError.Message, parseError.Raw)
+}
+
+func (parseErrors ParseErrorList) Error() string {
+	messages := make([]string, len(parseErrors))
+	for index, parseError := range parseErrors {
+		messages[index] = parseError.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// HasErrors reports whether the list contains anything more serious than a warning
+func (parseErrors ParseErrorList) HasErrors() bool {
+	for _, parseError := range parseErrors {
+		if parseError.Severity == ERROR_SEVERITY {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (code ParseErrorCode) String() string {
+	switch code {
+	case UNTERMINATED_BLOCK_ERROR:
+		return "Unterminated Block"
+
+	case BLOCK_TOO_LONG_ERROR:
+		return "Block Too Long"
+
+	case UNKNOWN_PARAMETER_ERROR:
+		return "Unknown Parameter"
+
+	case INVALID_PARAMETER_ERROR:
+		return "Invalid Parameter"
+
+	case DUPLICATE_PARAMETER_ERROR:
+		return "Duplicate Parameter"
+
+	case DUPLICATE_APERTURE_ERROR:
+		return "Duplicate Aperture"
+
+	case UNKNOWN_FUNCTION_CODE_ERROR:
+		return "Unknown Function Code"
+
+	case INVALID_DATA_BLOCK_ERROR:
+		return "Invalid Data Block"
+
+	case MISSING_FORMAT_ERROR:
+		return "Missing Format"
+
+	case DEPRECATED_CODE_ERROR:
+		return "Deprecated Code"
+
+	case DEPRECATED_PARAMETER_ERROR:
+		return "Deprecated Parameter"
+
+	case MISSING_D_CODE_ERROR:
+		return "Missing D Code"
+
+	case MISSING_END_OF_FILE_ERROR:
+		return "Missing End Of File"
+
+	default:
+		return "Unknown"
+	}
+}
+
+func (severity ParseSeverity) String() string {
+	switch severity {
+	case ERROR_SEVERITY:
+		return "Error"
+
+	case WARNING_SEVERITY:
+		return "Warning"
+
+	default:
+		return "Unknown"
+	}
+}
@@ -19,7 +19,7 @@ func parseDataBlock(dataBlock string, env *ParseEnvironment) (DataBlock, error)
 
 	if parsedDataBlock[0][1] == "G" && (parsedDataBlock[0][2] == "04" || parsedDataBlock[0][2] == "4") {
 		// Handle comments as a special case
-		return &IgnoreDataBlock{parsedDataBlock[0][3]}, nil
+		return &IgnoreDataBlock{comment: parsedDataBlock[0][3]}, nil
 	} else {
 		// Otherwise, finish processing the data block
 		return parseNonCommentBlock(parsedDataBlock[0][1], parsedDataBlock[0][2], parsedDataBlock[0][3], env)
@@ -31,6 +31,12 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 	case "G", "":
 		switch fnCode {
 		case "01", "02", "03", "54", "55", "": //NOTE: Codes 54 and 55 are deprecated, the empty function code is for coordinate data blocks with no function
+			if fnCode == "54" || fnCode == "55" {
+				if err := env.specViolation(DEPRECATED_CODE_ERROR, "Deprecated function code %s%s", fnLetter, fnCode); err != nil {
+					return nil, err
+				}
+			}
+
 			// Parse the D code out of remainder of the block
 			parsedDataBlock := dCodeDataBlockRegex.FindAllStringSubmatch(restOfBlock, -1)
 
@@ -41,13 +47,25 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 				return nil, fmt.Errorf("Unable to parse D code from data block %s: error 2", restOfBlock)
 			}
 
-			if len(parsedDataBlock[0][2]) > 0 { // This is where the D code was parsed to
-				if dCode, err := strconv.ParseInt(parsedDataBlock[0][2], 10, 32); err != nil {
+			dCodeString := parsedDataBlock[0][2] // This is where the D code was parsed to
+			if len(dCodeString) == 0 && len(parsedDataBlock[0][1]) > 0 {
+				// Coordinate data without a D code is deprecated, but older files rely on the previous D code being modal
+				if err := env.specViolation(MISSING_D_CODE_ERROR, "Coordinate data without a D code is deprecated (Coordinate data: %s)", parsedDataBlock[0][1]); err != nil {
+					return nil, err
+				}
+				if env.modalDCode == 0 {
+					return nil, newParseError(MISSING_D_CODE_ERROR, "Coordinate data without a D code, and no previous D code to reuse (Coordinate data: %s)", parsedDataBlock[0][1])
+				}
+				dCodeString = strconv.Itoa(env.modalDCode)
+			}
+
+			if len(dCodeString) > 0 {
+				if dCode, err := strconv.ParseInt(dCodeString, 10, 32); err != nil {
 					return nil, err
 				} else {
 					if dCode >= 10 {
 						// If the D code is >= 10, then this is a set aperture command
-						return &SetCurrentAperture{int(dCode)}, nil
+						return &SetCurrentAperture{apertureNumber: int(dCode)}, nil
 					} else {
 						// Else, this is an interpolation, so we set up a new interpolation with the
 						// function code and d code, and parse the coordinate data
@@ -92,17 +110,13 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 						default:
 							return nil, fmt.Errorf("Unknown D Code: %d", dCode)
 						}
+						env.modalDCode = int(dCode)
 
 						return parseCoordinateDataBlock(parsedDataBlock[0][1], newInterpolation, env)
 					}
 				}
 			} else {
 				// If there was no D code, then this is either a G01, G02, or G03 command by itself with no coordinate data
-				// First, make sure there is no coordinate data, because coordinate data without a D code is deprecated
-				if len(parsedDataBlock[0][1]) > 0 {
-					return nil, fmt.Errorf("Coordinate data without a D code is deprecated and not allowed (Coordinate data: %s)", parsedDataBlock[0][1])
-				}
-
 				newInterpolation := new(Interpolation)
 
 				switch fnCode {
@@ -126,60 +140,68 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 			}
 
 		case "36":
-			return &GraphicsStateChange{REGION_MODE_ON}, nil
+			return &GraphicsStateChange{fnCode: REGION_MODE_ON}, nil
 
 		case "37":
-			return &GraphicsStateChange{REGION_MODE_OFF}, nil
+			return &GraphicsStateChange{fnCode: REGION_MODE_OFF}, nil
 
 		case "70": //NOTE: Deprecated
-			return &GraphicsStateChange{SET_UNIT_INCH}, nil
+			return deprecatedGraphicsStateChange(SET_UNIT_INCH, fnLetter, fnCode, env)
 
 		case "71": //NOTE: Deprecated
-			return &GraphicsStateChange{SET_UNIT_MM}, nil
+			return deprecatedGraphicsStateChange(SET_UNIT_MM, fnLetter, fnCode, env)
 
 		case "74":
-			return &GraphicsStateChange{SINGLE_QUADRANT_MODE}, nil
+			return &GraphicsStateChange{fnCode: SINGLE_QUADRANT_MODE}, nil
 
 		case "75":
-			return &GraphicsStateChange{MULTI_QUADRANT_MODE}, nil
+			return &GraphicsStateChange{fnCode: MULTI_QUADRANT_MODE}, nil
 
 		case "90": //NOTE: Deprecated
-			return &GraphicsStateChange{SET_NOTATION_ABSOLUTE}, nil
+			return deprecatedGraphicsStateChange(SET_NOTATION_ABSOLUTE, fnLetter, fnCode, env)
 
 		case "91": //NOTE: Deprecated
-			return &GraphicsStateChange{SET_NOTATION_INCREMENTAL}, nil
+			return deprecatedGraphicsStateChange(SET_NOTATION_INCREMENTAL, fnLetter, fnCode, env)
 
 		default:
-			return nil, fmt.Errorf("Error: Unrecognized function code: %s%s", fnLetter, fnCode)
+			return nil, newParseError(UNKNOWN_FUNCTION_CODE_ERROR, "Error: Unrecognized function code: %s%s", fnLetter, fnCode)
 		}
 
 	case "M":
 		switch fnCode {
 		case "00": //NOTE: Deprecated
-			return &GraphicsStateChange{PROGRAM_STOP}, nil
+			return deprecatedGraphicsStateChange(PROGRAM_STOP, fnLetter, fnCode, env)
 
 		case "01": //NOTE: Deprecated
-			return &GraphicsStateChange{OPTIONAL_STOP}, nil
+			return deprecatedGraphicsStateChange(OPTIONAL_STOP, fnLetter, fnCode, env)
 
 		case "02":
-			return &GraphicsStateChange{END_OF_FILE}, nil
+			return &GraphicsStateChange{fnCode: END_OF_FILE}, nil
 
 		default:
-			return nil, fmt.Errorf("Error: Unrecognized function code: %s%s", fnLetter, fnCode)
+			return nil, newParseError(UNKNOWN_FUNCTION_CODE_ERROR, "Error: Unrecognized function code: %s%s", fnLetter, fnCode)
 		}
 
 	default:
-		return nil, fmt.Errorf("Error: Unrecognized function code: %s%s", fnLetter, fnCode)
+		return nil, newParseError(UNKNOWN_FUNCTION_CODE_ERROR, "Error: Unrecognized function code: %s%s", fnLetter, fnCode)
 	}
 
 	return nil, nil
 }
 
+func deprecatedGraphicsStateChange(stateChange FunctionCode, fnLetter string, fnCode string, env *ParseEnvironment) (DataBlock, error) {
+	if err := env.specViolation(DEPRECATED_CODE_ERROR, "Deprecated function code %s%s", fnLetter, fnCode); err != nil {
+		return nil, err
+	}
+
+	return &GraphicsStateChange{fnCode: stateChange}, nil
+}
+
 func parseCoordinateDataBlock(restOfBlock string, interpolation *Interpolation, env *ParseEnvironment) (*Interpolation, error) {
 	// Make sure the coordinate format has been set
 	// It is an error to have a coordinate data block before the coordinate format has been set
 	if !env.coordFormat.isSet {
-		return nil, fmt.Errorf("Encountered coordinate data block before coordinate format has been set")
+		return nil, newParseError(MISSING_FORMAT_ERROR, "Encountered coordinate data block before coordinate format has been set")
 	}
 
 	// Parse the rest of the data block
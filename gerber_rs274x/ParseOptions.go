@@ -0,0 +1,47 @@
+package gerber_rs274x
+
+type ParseMode int
+
+const (
+	LENIENT_PARSE_MODE ParseMode = iota
+	STRICT_PARSE_MODE
+)
+
+// ParseOptions controls how the parser deals with files that don't follow the spec to the letter.  The zero value
+// selects lenient parsing
+type ParseOptions struct {
+	// In strict mode, parsing stops at the first spec violation, including the use of deprecated codes and parameters.
+	// In lenient mode, the legacy RS-274X constructs that older CAD packages still produce (G54 prefixes, the IN, IP, MI, OF
+	// and SF parameters, coordinate data without a D code, a missing M02) are accepted and reported as warnings, and any
+	// blocks that can't be parsed at all are skipped and reported as errors
+	Mode ParseMode
+}
+
+// specViolation is called when the parser encounters something the spec doesn't allow, but which can still be handled
+// sensibly.  In strict mode the violation is returned as an error, in lenient mode it is recorded as a warning against
+// the block currently being parsed, and nil is returned so parsing can carry on
+func (env *ParseEnvironment) specViolation(code ParseErrorCode, format string, args ...interface{}) error {
+	parseError := newParseError(code, format, args...)
+
+	if env.options.Mode == STRICT_PARSE_MODE {
+		return parseError
+	}
+
+	parseError.Severity = WARNING_SEVERITY
+	env.warnings = append(env.warnings, parseError)
+
+	return nil
+}
+
+func (mode ParseMode) String() string {
+	switch mode {
+	case LENIENT_PARSE_MODE:
+		return "Lenient"
+
+	case STRICT_PARSE_MODE:
+		return "Strict"
+
+	default:
+		return "Unknown"
+	}
+}
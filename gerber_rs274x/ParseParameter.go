@@ -8,28 +8,24 @@ import (
 
 func parseParameter(parameter string, env *ParseEnvironment) (DataBlock, error) {
 	// All parameter blocks must have at least 3 characters (the two character parameter code, and at least one character of arguments)
-	// So we check for at least that length here, so we can slice to at least the third character below
-	if len(parameter) < 3 && parameter != "TD" {
-		return nil, fmt.Errorf("Error: Unrecognized parameter string %s", parameter)
+	// So we check for at least that length here, so we can slice to at least the third character below.  The exceptions are
+	// TD, which clears all attributes, and SR, which closes a step and repeat block
+	if len(parameter) < 3 && parameter != "TD" && parameter != "SR" {
+		return nil, newParseError(UNKNOWN_PARAMETER_ERROR, "Error: Unrecognized parameter string %s", parameter)
 	}
 
 	switch parameter[0:2] {
-	case "TA":
-		newTAParam := new(FormatSpecificationParameter)
-		newTAParam.paramCode = TA_PARAMETER
-		return parseTAParameter(newTAParam, parameter[2:], env)
-	case "TD":
-		newTDParam := new(FormatSpecificationParameter)
-		newTDParam.paramCode = TD_PARAMETER
-		return parseTDParameter(newTDParam, parameter[2:], env)
 	case "TF":
-		newTFParam := new(FormatSpecificationParameter)
-		newTFParam.paramCode = TF_PARAMETER
-		return parseTFParameter(newTFParam, parameter[2:], env)
+		return parseAttributeParameter(TF_PARAMETER, parameter[2:])
+
+	case "TA":
+		return parseAttributeParameter(TA_PARAMETER, parameter[2:])
+
 	case "TO":
-		newTOParam := new(FormatSpecificationParameter)
-		newTOParam.paramCode = TO_PARAMETER
-		return parseTOParameter(newTOParam, parameter[2:], env)
+		return parseAttributeParameter(TO_PARAMETER, parameter[2:])
+
+	case "TD":
+		return parseAttributeParameter(TD_PARAMETER, parameter[2:])
 
 	case "FS":
 		newFSParam := new(FormatSpecificationParameter)
@@ -61,45 +57,93 @@ func parseParameter(parameter string, env *ParseEnvironment) (DataBlock, error)
 		newLPParam.paramCode = LP_PARAMETER
 		return parseLPParameter(newLPParam, parameter[2:])
 
+	case "IN", "LN", "AS", "IR", "IP", "MI", "OF", "SF":
+		// These image parameters were removed from the spec, but older files still contain them
+		if err := env.specViolation(DEPRECATED_PARAMETER_ERROR, "Deprecated parameter %s", parameter[0:2]); err != nil {
+			return nil, err
+		}
+		return parseImageParameter(parameter[0:2], parameter[2:])
+
 	default:
-		return nil, fmt.Errorf("Error: Unrecognized parameter code %s", parameter[0:2])
+		return nil, newParseError(UNKNOWN_PARAMETER_ERROR, "Error: Unrecognized parameter code %s", parameter[0:2])
 	}
 
 	return nil, nil
 }
 
-func parseTAParameter(taParameter *FormatSpecificationParameter, restOfParameter string, env *ParseEnvironment) (DataBlock, error) {
-	parsedTA := taParameterRegex.FindAllStringSubmatch(restOfParameter, -1)
-	// fmt.Println("parsedTA = ", parsedTA)
-	attr := Attribute{typ: "A", name: parsedTA[0][1], args: parsedTA[0][2:]}
-	return attr, nil
-}
+func parseAttributeParameter(paramCode ParameterCode, restOfParameter string) (DataBlock, error) {
+	// An attribute is its name followed by a comma separated list of values.  Standard attribute names start with a ".",
+	// user attribute names don't, but both are kept exactly as written
+	fields := strings.Split(restOfParameter, ",")
+	attribute := &Attribute{paramCode: paramCode, name: fields[0], values: fields[1:]}
 
-func parseTDParameter(fsParameter *FormatSpecificationParameter, restOfParameter string, env *ParseEnvironment) (DataBlock, error) {
-	parsedTD := tdParameterRegex.FindAllStringSubmatch(restOfParameter, -1)
-	// fmt.Println("parsedTD = ", parsedTD)
-	var attr Attribute
-	switch {
-	case len(parsedTD) == 0:
-		attr = Attribute{typ: "D"}
-	case len(parsedTD) == 1 && len(parsedTD[0]) > 1:
-		attr = Attribute{typ: "D", name: parsedTD[0][1], args: parsedTD[0][2:]}
-	}
-	return attr, nil
-}
+	if len(attribute.name) == 0 && (paramCode != TD_PARAMETER || len(restOfParameter) > 0) {
+		return nil, fmt.Errorf("Missing attribute name in attribute parameter %s", restOfParameter)
+	}
+
+	if paramCode == TD_PARAMETER && len(attribute.values) > 0 {
+		return nil, fmt.Errorf("Attribute delete parameter can't have values: %s", restOfParameter)
+	}
+
+	if strings.HasPrefix(attribute.name, ".C") {
+		if err := validateComponentAttribute(attribute); err != nil {
+			return nil, err
+		}
+	}
 
-func parseTFParameter(fsParameter *FormatSpecificationParameter, restOfParameter string, env *ParseEnvironment) (DataBlock, error) {
-	parsedTF := tfParameterRegex.FindAllStringSubmatch(restOfParameter, -1)
-	// fmt.Println("parsedTF= ", parsedTF)
-	attr := Attribute{typ: "F", name: parsedTF[0][1], args: parsedTF[0][2:]}
-	return attr, nil
+	return attribute, nil
 }
 
-func parseTOParameter(fsParameter *FormatSpecificationParameter, restOfParameter string, env *ParseEnvironment) (DataBlock, error) {
-	parsedTO := toParameterRegex.FindAllStringSubmatch(restOfParameter, -1)
-	// fmt.Println("parsedTO = ", parsedTO)
-	attr := Attribute{typ: "O", name: parsedTO[0][1], args: parsedTO[0][2:]}
-	return attr, nil
+// validateComponentAttribute checks the Gerber X3 component attributes (.C, and the ones starting with .C that describe
+// the component, such as .CRot and .CFtp).  These can only be object attributes, since they're attached to the flashes
+// and draws on assembly layers that represent a component
+func validateComponentAttribute(attribute *Attribute) error {
+	var minValues, maxValues int
+
+	switch attribute.name {
+	case ".C", ".CRot", ".CHgt", ".CMnt", ".CMfr", ".CMPN", ".CVal", ".CFtp", ".CPgN", ".CPgD", ".CLbN", ".CLbD":
+		minValues, maxValues = 1, 1
+
+	case ".CSup":
+		// Supplier, supplier part number pairs
+		minValues, maxValues = 2, -1
+
+	default:
+		// Not a component attribute, just a standard attribute that happens to start with .C
+		return nil
+	}
+
+	if attribute.paramCode == TD_PARAMETER {
+		return nil
+	} else if attribute.paramCode != TO_PARAMETER {
+		return fmt.Errorf("Component attribute %s must be an object attribute", attribute.name)
+	}
+
+	if (len(attribute.values) < minValues) || (maxValues >= 0 && len(attribute.values) > maxValues) {
+		return fmt.Errorf("Wrong number of values for component attribute %s: %v", attribute.name, attribute.values)
+	}
+
+	switch attribute.name {
+	case ".CSup":
+		if len(attribute.values)%2 != 0 {
+			return fmt.Errorf("Component attribute %s must have supplier and part number pairs: %v", attribute.name, attribute.values)
+		}
+
+	case ".CRot", ".CHgt":
+		if _, err := strconv.ParseFloat(attribute.values[0], 64); err != nil {
+			return fmt.Errorf("Component attribute %s must be a number.  Received %s", attribute.name, attribute.values[0])
+		}
+
+	case ".CMnt":
+		switch attribute.values[0] {
+		case "TH", "SMD", "Pressfit", "Fiducial", "Other":
+
+		default:
+			return fmt.Errorf("Unknown component mount type: %s", attribute.values[0])
+		}
+	}
+
+	return nil
 }
 
 func parseFSParameter(fsParameter *FormatSpecificationParameter, restOfParameter string, env *ParseEnvironment) (DataBlock, error) {
@@ -107,8 +151,12 @@ func parseFSParameter(fsParameter *FormatSpecificationParameter, restOfParameter
 
 	// Make sure we haven't already seen an FS parameter
 	// It's only legal to have one FS parameter per file
+	// In lenient mode, the first one wins and any others are ignored
 	if env.coordFormat.isSet {
-		return nil, fmt.Errorf("Illegal 2nd FS parameter encountered")
+		if err := env.specViolation(DUPLICATE_PARAMETER_ERROR, "Illegal 2nd FS parameter encountered"); err != nil {
+			return nil, err
+		}
+		return &IgnoreDataBlock{comment: "FS" + restOfParameter}, nil
 	}
 
 	// Make sure we captured the number of subexpressions we expected
@@ -220,7 +268,7 @@ func parseMOParameter(moParameter *ModeParameter, restOfParameter string, env *P
 	// Make sure we haven't already seen an MO parameter
 	// It's only legal to have one MO parameter per file
 	if env.unitsSet {
-		return nil, fmt.Errorf("Illegal 2nd MO parameter encountered")
+		return nil, newParseError(DUPLICATE_PARAMETER_ERROR, "Illegal 2nd MO parameter encountered")
 	}
 
 	if len(restOfParameter) < 2 {
@@ -269,7 +317,7 @@ func parseADParameter(adParameter *ApertureDefinitionParameter, restOfParameter
 
 	// Make sure that this parameter hasn't already been defined.  It's illegal to re-use the same D code
 	if _, exists := env.aperturesDefined[newADParam.apertureNumber]; exists {
-		return nil, fmt.Errorf("Illegal duplicate aperture D code encountered: %d", newADParam.apertureNumber)
+		return nil, newParseError(DUPLICATE_APERTURE_ERROR, "Illegal duplicate aperture D code encountered: %d", newADParam.apertureNumber)
 	}
 
 	// Parse the aperture type
@@ -582,8 +630,12 @@ func parseAMParameter(amParameter *ApertureMacroParameter, restOfParameter strin
 	// Populate the name
 	amParameter.macroName = blocks[0]
 
-	// Parse the rest of the macro
-	return parseApertureMacro(amParameter, blocks[1:])
+	// Parse the rest of the macro.  A failed parse must return an untyped nil, so callers see that no data block was parsed
+	if parsedMacro, err := parseApertureMacro(amParameter, blocks[1:]); err != nil {
+		return nil, err
+	} else {
+		return parsedMacro, nil
+	}
 }
 
 func parseSRParameter(srParameter *StepAndRepeatParameter, restOfParameter string) (DataBlock, error) {
@@ -686,6 +738,109 @@ func parseLPParameter(lpParameter *LevelPolarityParameter, restOfParameter strin
 	return lpParameter, nil
 }
 
+func parseImageParameter(paramCode string, restOfParameter string) (DataBlock, error) {
+	switch paramCode {
+	case "IN":
+		return &ImageNameParameter{paramCode: IN_PARAMETER, name: restOfParameter}, nil
+
+	case "LN":
+		return &LevelNameParameter{paramCode: LN_PARAMETER, name: restOfParameter}, nil
+
+	case "AS":
+		switch restOfParameter {
+		case "AXBY":
+			return &AxisSelectParameter{paramCode: AS_PARAMETER, isAXBY: true}, nil
+
+		case "AYBX":
+			return &AxisSelectParameter{paramCode: AS_PARAMETER, isAXBY: false}, nil
+
+		default:
+			return nil, fmt.Errorf("Unknown axis select argument: %s", restOfParameter)
+		}
+
+	case "IR":
+		switch restOfParameter {
+		case "0", "90", "180", "270":
+			rotation, _ := strconv.Atoi(restOfParameter)
+			return &ImageRotationParameter{paramCode: IR_PARAMETER, rotation: rotation}, nil
+
+		default:
+			return nil, fmt.Errorf("Image rotation must be 0, 90, 180 or 270 degrees.  Received %s", restOfParameter)
+		}
+
+	case "IP":
+		switch restOfParameter {
+		case "POS":
+			return &ImagePolarityParameter{paramCode: IP_PARAMETER, polarity: DARK_POLARITY}, nil
+
+		case "NEG":
+			return &ImagePolarityParameter{paramCode: IP_PARAMETER, polarity: CLEAR_POLARITY}, nil
+
+		default:
+			return nil, fmt.Errorf("Unknown image polarity argument: %s", restOfParameter)
+		}
+
+	case "MI":
+		// Axes that aren't mentioned aren't mirrored
+		aMirror, bMirror, err := parseImageAxisValues(paramCode, restOfParameter, 0.0)
+		if err != nil {
+			return nil, err
+		}
+		if (aMirror != 0.0 && aMirror != 1.0) || (bMirror != 0.0 && bMirror != 1.0) {
+			return nil, fmt.Errorf("Mirror image arguments must be 0 or 1.  Received %s", restOfParameter)
+		}
+		return &MirrorImageParameter{paramCode: MI_PARAMETER, axisAMirror: (aMirror == 1.0), axisBMirror: (bMirror == 1.0)}, nil
+
+	case "OF":
+		// Axes that aren't mentioned aren't offset
+		aOffset, bOffset, err := parseImageAxisValues(paramCode, restOfParameter, 0.0)
+		if err != nil {
+			return nil, err
+		}
+		return &OffsetParameter{paramCode: OF_PARAMETER, axisAOffset: aOffset, axisBOffset: bOffset}, nil
+
+	case "SF":
+		// Axes that aren't mentioned aren't scaled
+		aScale, bScale, err := parseImageAxisValues(paramCode, restOfParameter, 1.0)
+		if err != nil {
+			return nil, err
+		}
+		return &ScaleFactorParameter{paramCode: SF_PARAMETER, axisAScale: aScale, axisBScale: bScale}, nil
+
+	default:
+		return nil, newParseError(UNKNOWN_PARAMETER_ERROR, "Error: Unrecognized parameter code %s", paramCode)
+	}
+}
+
+// parseImageAxisValues parses the "A<value>B<value>" arguments shared by several of the image parameters.  Either value
+// may be left out, in which case it takes the supplied default
+func parseImageAxisValues(paramCode string, restOfParameter string, defaultValue float64) (aValue float64, bValue float64, err error) {
+	parsedValues := imageAxisParameterRegex.FindAllStringSubmatch(restOfParameter, -1)
+
+	// Make sure we captured the number of subexpressions we expected
+	if len(parsedValues) != 1 {
+		return 0.0, 0.0, fmt.Errorf("Unable to parse %s Parameter %s: error 1", paramCode, restOfParameter)
+	} else if len(parsedValues[0]) != 3 {
+		return 0.0, 0.0, fmt.Errorf("Unable to parse %s Parameter %s: error 2", paramCode, restOfParameter)
+	}
+
+	aValue, bValue = defaultValue, defaultValue
+
+	if len(parsedValues[0][1]) > 0 {
+		if aValue, err = strconv.ParseFloat(parsedValues[0][1], 64); err != nil {
+			return 0.0, 0.0, err
+		}
+	}
+
+	if len(parsedValues[0][2]) > 0 {
+		if bValue, err = strconv.ParseFloat(parsedValues[0][2], 64); err != nil {
+			return 0.0, 0.0, err
+		}
+	}
+
+	return aValue, bValue, nil
+}
+
 func modifierFieldsFunc(char rune) bool {
 	return char == 'X'
 }
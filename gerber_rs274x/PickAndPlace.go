@@ -0,0 +1,102 @@
+package gerber_rs274x
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// ComponentPlacement is one row of a pick and place table, taken from the component attributes on a Gerber X3 assembly
+// layer.  Coordinates are in millimetres, and the rotation is in degrees counterclockwise
+type ComponentPlacement struct {
+	Refdes       string
+	X            float64
+	Y            float64
+	Rotation     float64
+	Side         string
+	Footprint    string
+	Value        string
+	Manufacturer string
+	MPN          string
+	MountType    string
+}
+
+// GetComponentPlacements builds the pick and place table for an X3 assembly layer (a file whose .FileFunction is
+// Component).  Each component's location is the flash of its ComponentMain aperture, which marks the component's centroid
+func (image *GerberImage) GetComponentPlacements() []*ComponentPlacement {
+	// The side comes from the file function, eg. Component,L1,Top
+	var side string
+	if fileFunction := image.GetFileFunction(); len(fileFunction) >= 3 && fileFunction[0] == "Component" {
+		switch fileFunction[2] {
+		case "Top":
+			side = "Top"
+
+		case "Bot":
+			side = "Bottom"
+		}
+	}
+
+	placements := make([]*ComponentPlacement, 0, 10)
+	for _, graphicsObject := range image.graphicsObjects {
+		if graphicsObject.objectType != FLASH_OBJECT || graphicsObject.apertureAttributes.GetFirst(".AperFunction") != "ComponentMain" {
+			continue
+		}
+
+		objectAttributes := graphicsObject.objectAttributes
+		placement := &ComponentPlacement{
+			Refdes:       objectAttributes.GetFirst(".C"),
+			X:            graphicsObject.start.X,
+			Y:            graphicsObject.start.Y,
+			Side:         side,
+			Footprint:    objectAttributes.GetFirst(".CFtp"),
+			Value:        objectAttributes.GetFirst(".CVal"),
+			Manufacturer: objectAttributes.GetFirst(".CMfr"),
+			MPN:          objectAttributes.GetFirst(".CMPN"),
+			MountType:    objectAttributes.GetFirst(".CMnt"),
+		}
+
+		// The rotation was already checked to be a number when the attribute was parsed, and it defaults to 0 when missing
+		if rotation := objectAttributes.GetFirst(".CRot"); len(rotation) > 0 {
+			placement.Rotation, _ = strconv.ParseFloat(rotation, 64)
+		}
+
+		placements = append(placements, placement)
+	}
+
+	sort.SliceStable(placements, func(i int, j int) bool {
+		return placements[i].Refdes < placements[j].Refdes
+	})
+
+	return placements
+}
+
+// WritePickAndPlaceCSV writes a pick and place table as CSV, with a header row
+func WritePickAndPlaceCSV(out io.Writer, placements []*ComponentPlacement) error {
+	csvWriter := csv.NewWriter(out)
+	header := []string{"Ref", "X (mm)", "Y (mm)", "Rotation", "Side", "Footprint", "Value", "Manufacturer", "MPN", "Mount"}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, placement := range placements {
+		record := []string{
+			placement.Refdes,
+			strconv.FormatFloat(placement.X, 'f', -1, 64),
+			strconv.FormatFloat(placement.Y, 'f', -1, 64),
+			strconv.FormatFloat(placement.Rotation, 'f', -1, 64),
+			placement.Side,
+			placement.Footprint,
+			placement.Value,
+			placement.Manufacturer,
+			placement.MPN,
+			placement.MountType,
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,193 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// PocketPattern chooses how the tool works its way over an area being cleared
+type PocketPattern int
+
+const (
+	// Offset pockets follow the edge of the area, stepping inward one ring at a time
+	OFFSET_POCKET_PATTERN PocketPattern = iota
+	// Zigzag pockets sweep back and forth in straight rows, then run round the edge once to clean it up
+	ZIGZAG_POCKET_PATTERN
+)
+
+// PocketCAM holds the settings for clearing all of the copper between the features of a layer, usually with a bigger tool
+// than the isolation cuts.  The isolation cuts are still needed for the gaps the bigger tool can't fit into.  Coordinates
+// are in millimetres (with the image parameters applied) before they go through TranslateScale
+type PocketCAM struct {
+	Wrt            io.WriteCloser
+	SafeZ          float64
+	CutZ           float64
+	PlungeF        int
+	CutF           int
+	ToolDiameter   float64
+	SpindleSpeed   int     // In RPM, DEFAULT_SPINDLE_SPEED when zero
+	Overlap        float64 // The fraction of the tool diameter each pass overlaps the one before it, from 0 up to (but not including) 1
+	Pattern        PocketPattern
+	JoinTolerance  float64
+	TranslateScale func(float64, float64) (float64, float64)
+}
+
+// GenerateClearing clears the copper that isn't part of the design, everywhere inside the board outline.  The tool stays
+// a radius away from the copper and from the board edge, so the area its center can cover is worked out first, and then
+// filled with the chosen pattern
+func GenerateClearing(cam *PocketCAM, copperFile []DataBlock, outlineFile []DataBlock) error {
+	if cam.ToolDiameter <= 0.0 {
+		return fmt.Errorf("Tool diameter must be positive, got %f", cam.ToolDiameter)
+	}
+	if cam.Overlap < 0.0 || cam.Overlap >= 1.0 {
+		return fmt.Errorf("Pass overlap must be at least 0 and less than 1, got %f", cam.Overlap)
+	}
+
+	tolerance := cam.ToolDiameter / 50.0
+	toolRadius := cam.ToolDiameter / 2.0
+	stepOver := cam.ToolDiameter * (1.0 - cam.Overlap)
+
+	copperImage, err := InterpretGerberFile(copperFile)
+	if err != nil {
+		return err
+	}
+	copper, err := copperImage.Flatten(tolerance)
+	if err != nil {
+		return err
+	}
+
+	outlineImage, err := InterpretGerberFile(outlineFile)
+	if err != nil {
+		return err
+	}
+	joinTolerance := cam.JoinTolerance
+	if joinTolerance <= 0.0 {
+		joinTolerance = DEFAULT_OUTLINE_JOIN_TOLERANCE
+	}
+	board, err := getBoardArea(outlineImage, joinTolerance, tolerance)
+	if err != nil {
+		return err
+	}
+
+	insideBoard, err := OffsetPolygons(board, -toolRadius, tolerance)
+	if err != nil {
+		return err
+	}
+	aroundCopper, err := OffsetPolygons(copper, toolRadius, tolerance)
+	if err != nil {
+		return err
+	}
+	reachable := ClipPolygons(getPolygonsWithHolesContours(insideBoard), getPolygonsWithHolesContours(aroundCopper), DIFFERENCE_OPERATION)
+
+	var paths [][]Point
+	switch cam.Pattern {
+	case OFFSET_POCKET_PATTERN:
+		// Every ring is the one before it moved in by another step, until there's nothing left.  Stepping in from the ring
+		// before, rather than from the edge, keeps the offsets small, which keeps them fast
+		for inset := reachable; len(inset) > 0; {
+			for _, contour := range orderContours(getPolygonsWithHolesContours(inset), Point{}) {
+				paths = append(paths, append(contour, contour[0]))
+			}
+
+			if inset, err = OffsetPolygons(inset, -stepOver, tolerance); err != nil {
+				return err
+			}
+		}
+
+	case ZIGZAG_POCKET_PATTERN:
+		paths = getZigzagPaths(getPolygonsWithHolesContours(reachable), stepOver)
+		for _, contour := range orderContours(getPolygonsWithHolesContours(reachable), Point{}) {
+			paths = append(paths, append(contour, contour[0]))
+		}
+
+	default:
+		return fmt.Errorf("Unknown pocket pattern %d", cam.Pattern)
+	}
+
+	w := cam.Wrt
+	fmt.Fprintf(w, "; My PocketCAM\n")
+	fmt.Fprintf(w, "G90G40G17G21\n")
+	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+	fmt.Fprintf(w, "M3S%d\n", getSpindleSpeed(cam.SpindleSpeed))
+
+	for _, path := range paths {
+		x, y := cam.TranslateScale(path[0].X, path[0].Y)
+		fmt.Fprintf(w, "G00X%fY%f\n", x, y)
+		fmt.Fprintf(w, "G01Z%fF%d\n", cam.CutZ, cam.PlungeF)
+		for _, point := range path[1:] {
+			x, y = cam.TranslateScale(point.X, point.Y)
+			fmt.Fprintf(w, "G01X%fY%fF%d\n", x, y, cam.CutF)
+		}
+		fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+	}
+
+	fmt.Fprintf(w, "M5\n")
+
+	return nil
+}
+
+// getZigzagPaths sweeps rows one step apart across the area, with every stretch of a row that's inside the area cut as
+// its own path.  Rows alternate direction, so the tool only has a short hop from the end of one row to the next
+func getZigzagPaths(contours []Polygon, stepOver float64) [][]Point {
+	yMin, yMax := math.MaxFloat64, -math.MaxFloat64
+	for _, contour := range contours {
+		for _, point := range contour {
+			yMin = math.Min(yMin, point.Y)
+			yMax = math.Max(yMax, point.Y)
+		}
+	}
+	if yMin > yMax {
+		return nil
+	}
+
+	// Spread the rows evenly between the bottom and top of the area, no further apart than the step
+	rows := int(math.Ceil((yMax-yMin)/stepOver)) + 1
+	spacing := 0.0
+	if rows > 1 {
+		spacing = (yMax - yMin) / float64(rows-1)
+	}
+
+	paths := make([][]Point, 0, rows)
+	reverse := false
+	for row := 0; row < rows; row++ {
+		y := yMin + (float64(row) * spacing)
+		spans := scanlineSpans(contours, y)
+		if reverse {
+			for i, j := 0, len(spans)-1; i < j; i, j = i+1, j-1 {
+				spans[i], spans[j] = spans[j], spans[i]
+			}
+		}
+
+		for _, span := range spans {
+			if reverse {
+				paths = append(paths, []Point{{span[1], y}, {span[0], y}})
+			} else {
+				paths = append(paths, []Point{{span[0], y}, {span[1], y}})
+			}
+		}
+
+		reverse = !reverse
+	}
+
+	return paths
+}
+
+// getBoardArea fills in the closed contours of an outline layer, with the contours inside an odd number of others (the
+// cutouts) left as holes
+func getBoardArea(outline *GerberImage, joinTolerance float64, tolerance float64) ([]PolygonWithHoles, error) {
+	contours, err := getOutlineContours(outline, joinTolerance, tolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	oriented := make([]Polygon, len(contours))
+	for i, contour := range contours {
+		oriented[i] = contour.counterClockwise()
+		if isOutlineCutout(contours, i) {
+			oriented[i] = oriented[i].Reverse()
+		}
+	}
+
+	return ClipPolygons(oriented, nil, UNION_OPERATION), nil
+}
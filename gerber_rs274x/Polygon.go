@@ -0,0 +1,339 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Polygon is a closed contour, with an implied edge from the last point back to the first.  Solid contours run
+// counterclockwise, and holes run clockwise
+type Polygon []Point
+
+// PolygonLayer is a set of polygons filled with the nonzero winding rule, so overlapping solid contours combine into
+// their union, and a clockwise hole inside a solid contour cuts it out.  Shapes are built up from layers in order, with
+// clear layers erasing whatever the layers before them have drawn
+type PolygonLayer struct {
+	Polygons []Polygon
+	Polarity Polarity
+}
+
+// GetAperturePolygons converts an aperture into polygons, in aperture coordinates with the origin at the flash point.
+// Macro apertures need their macro definition, which is ignored for all other apertures.  Arcs are approximated by chords
+// that never stray more than the tolerance from the true curve
+func GetAperturePolygons(aperture Aperture, apertureMacro []ApertureMacroDataBlock, tolerance float64) ([]PolygonLayer, error) {
+	if tolerance <= 0.0 {
+		return nil, fmt.Errorf("Chord tolerance must be positive, got %f", tolerance)
+	}
+
+	return aperture.getPolygons(apertureMacro, tolerance)
+}
+
+// Area returns the signed area of the polygon, which is positive for counterclockwise polygons
+func (polygon Polygon) Area() float64 {
+	area := 0.0
+	for i := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		area += (polygon[i].X * next.Y) - (next.X * polygon[i].Y)
+	}
+
+	return area / 2.0
+}
+
+func (polygon Polygon) IsCounterClockwise() bool {
+	return polygon.Area() > 0.0
+}
+
+func (polygon Polygon) Reverse() Polygon {
+	reversed := make(Polygon, len(polygon))
+	for i, point := range polygon {
+		reversed[len(polygon)-1-i] = point
+	}
+
+	return reversed
+}
+
+// Transform maps every point of the polygon through the transform.  Mirroring transforms would turn solid contours into
+// holes, so the result is reversed for them to keep its orientation
+func (polygon Polygon) Transform(transform AffineTransform) Polygon {
+	transformed := make(Polygon, len(polygon))
+	for i, point := range polygon {
+		transformed[i] = transform.Apply(point)
+	}
+
+	if transform.IsMirrored() {
+		return transformed.Reverse()
+	}
+
+	return transformed
+}
+
+func (polygon Polygon) Translate(dx float64, dy float64) Polygon {
+	return polygon.Transform(AffineTransform{xx: 1.0, yy: 1.0, x0: dx, y0: dy})
+}
+
+// counterClockwise returns the polygon running counterclockwise, reversing it if necessary
+func (polygon Polygon) counterClockwise() Polygon {
+	if polygon.Area() < 0.0 {
+		return polygon.Reverse()
+	}
+
+	return polygon
+}
+
+// simplify drops the vertices that can be left out without the outline moving by more than the tolerance, using the
+// Douglas-Peucker algorithm.  Chains of short chords (from arcs, or from earlier offsets and clipping) collapse into a few
+// longer edges
+func (polygon Polygon) simplify(tolerance float64) Polygon {
+	if len(polygon) <= 3 {
+		return polygon
+	}
+
+	// A closed contour is split into two chains at its first vertex and the vertex furthest from it, which both stay
+	var far int
+	farDistance := -1.0
+	for i, point := range polygon {
+		if distance := math.Hypot(point.X-polygon[0].X, point.Y-polygon[0].Y); distance > farDistance {
+			far, farDistance = i, distance
+		}
+	}
+
+	keep := make([]bool, len(polygon))
+	keep[0], keep[far] = true, true
+
+	var simplifyChain func(first int, last int)
+	simplifyChain = func(first int, last int) {
+		a, b := polygon[first%len(polygon)], polygon[last%len(polygon)]
+		dX, dY := b.X-a.X, b.Y-a.Y
+		length := math.Hypot(dX, dY)
+
+		worst, worstDistance := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			point := polygon[i]
+			var distance float64
+			if length == 0.0 {
+				distance = math.Hypot(point.X-a.X, point.Y-a.Y)
+			} else {
+				distance = math.Abs(((point.X-a.X)*dY)-((point.Y-a.Y)*dX)) / length
+			}
+			if distance > worstDistance {
+				worst, worstDistance = i, distance
+			}
+		}
+
+		if worst >= 0 {
+			keep[worst] = true
+			simplifyChain(first, worst)
+			simplifyChain(worst, last)
+		}
+	}
+	simplifyChain(0, far)
+	simplifyChain(far, len(polygon))
+
+	simplified := make(Polygon, 0, len(polygon))
+	for i, point := range polygon {
+		if keep[i] {
+			simplified = append(simplified, point)
+		}
+	}
+
+	return simplified
+}
+
+func transformLayers(layers []PolygonLayer, transform AffineTransform) []PolygonLayer {
+	transformed := make([]PolygonLayer, len(layers))
+	for i, layer := range layers {
+		transformed[i].Polarity = layer.Polarity
+		transformed[i].Polygons = make([]Polygon, len(layer.Polygons))
+		for j, polygon := range layer.Polygons {
+			transformed[i].Polygons[j] = polygon.Transform(transform)
+		}
+	}
+
+	return transformed
+}
+
+// arcSegmentCount works out how many chords are needed to approximate an arc without any of them straying more than
+// the tolerance from it.  Full circles always get at least 3, so they don't collapse to a line
+func arcSegmentCount(radius float64, sweepAngle float64, tolerance float64) int {
+	maxStep := TWO_PI / 3.0
+	if tolerance < radius {
+		maxStep = math.Min(maxStep, 2.0*math.Acos(1.0-(tolerance/radius)))
+	}
+
+	return int(math.Max(1.0, math.Ceil((math.Abs(sweepAngle)/maxStep)-1e-9)))
+}
+
+// arcPoints returns the points along an arc, including both of its ends.  The sweep is positive for counterclockwise arcs
+func arcPoints(center Point, radius float64, startAngle float64, sweepAngle float64, tolerance float64) []Point {
+	segments := arcSegmentCount(radius, sweepAngle, tolerance)
+	step := sweepAngle / float64(segments)
+
+	points := make([]Point, segments+1)
+	for i := range points {
+		angle := startAngle + (step * float64(i))
+		points[i] = Point{center.X + (radius * math.Cos(angle)), center.Y + (radius * math.Sin(angle))}
+	}
+
+	return points
+}
+
+func circlePolygon(center Point, radius float64, tolerance float64) Polygon {
+	points := arcPoints(center, radius, 0.0, TWO_PI, tolerance)
+
+	// The last point is the same as the first
+	return Polygon(points[:len(points)-1])
+}
+
+func rectanglePolygon(xMin float64, xMax float64, yMin float64, yMax float64) Polygon {
+	return Polygon{{xMin, yMin}, {xMax, yMin}, {xMax, yMax}, {xMin, yMax}}
+}
+
+// obroundPolygon builds a rectangle with semicircular ends on its shorter sides, centered on the origin
+func obroundPolygon(xSize float64, ySize float64, tolerance float64) Polygon {
+	if xSize == ySize {
+		return circlePolygon(Point{}, xSize/2.0, tolerance)
+	}
+
+	var polygon Polygon
+	if xSize > ySize {
+		radius := ySize / 2.0
+		rectRadiusX := (xSize - ySize) / 2.0
+		polygon = append(polygon, arcPoints(Point{rectRadiusX, 0.0}, radius, -ONE_HALF_PI, math.Pi, tolerance)...)
+		polygon = append(polygon, arcPoints(Point{-rectRadiusX, 0.0}, radius, ONE_HALF_PI, math.Pi, tolerance)...)
+	} else {
+		radius := xSize / 2.0
+		rectRadiusY := (ySize - xSize) / 2.0
+		polygon = append(polygon, arcPoints(Point{0.0, rectRadiusY}, radius, 0.0, math.Pi, tolerance)...)
+		polygon = append(polygon, arcPoints(Point{0.0, -rectRadiusY}, radius, math.Pi, math.Pi, tolerance)...)
+	}
+
+	return polygon
+}
+
+// regularPolygon builds a regular polygon with its first vertex at the given angle (in radians) from its center
+func regularPolygon(center Point, diameter float64, numVertices int, rotation float64) Polygon {
+	radius := diameter / 2.0
+	vertexAngle := TWO_PI / float64(numVertices)
+
+	polygon := make(Polygon, numVertices)
+	for i := range polygon {
+		angle := rotation + (vertexAngle * float64(i))
+		polygon[i] = Point{center.X + (radius * math.Cos(angle)), center.Y + (radius * math.Sin(angle))}
+	}
+
+	return polygon
+}
+
+// convexHull returns the counterclockwise convex hull of a set of points
+func convexHull(points []Point) Polygon {
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i int, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+
+	if len(sorted) < 3 {
+		return Polygon(sorted)
+	}
+
+	cross := func(o Point, a Point, b Point) float64 {
+		return ((a.X - o.X) * (b.Y - o.Y)) - ((a.Y - o.Y) * (b.X - o.X))
+	}
+
+	// Andrew's monotone chain: build the lower hull left to right, then the upper hull right to left
+	hull := make(Polygon, 0, 2*len(sorted))
+	for _, point := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], point) <= 0.0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, point)
+	}
+
+	lowerSize := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		for len(hull) >= lowerSize && cross(hull[len(hull)-2], hull[len(hull)-1], sorted[i]) <= 0.0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, sorted[i])
+	}
+
+	// The last point is the same as the first
+	return hull[:len(hull)-1]
+}
+
+// strokeLinearPolygon sweeps a shape in a straight line from start to end.  Circles sweep into a rectangle with round
+// ends, and any other convex shape sweeps into the convex hull of its start and end positions
+func strokeLinearPolygon(shape Polygon, radius float64, isCircle bool, start Point, end Point, tolerance float64) Polygon {
+	if isCircle {
+		length := math.Hypot(end.X-start.X, end.Y-start.Y)
+		if length == 0.0 {
+			return circlePolygon(start, radius, tolerance)
+		}
+
+		// Round the back of the start point, then round the front of the end point
+		strokeAngle := math.Atan2(end.Y-start.Y, end.X-start.X)
+		polygon := arcPoints(start, radius, strokeAngle+ONE_HALF_PI, math.Pi, tolerance)
+		polygon = append(polygon, arcPoints(end, radius, strokeAngle-ONE_HALF_PI, math.Pi, tolerance)...)
+
+		return Polygon(polygon)
+	}
+
+	points := make([]Point, 0, 2*len(shape))
+	for _, point := range shape {
+		points = append(points, Point{point.X + start.X, point.Y + start.Y}, Point{point.X + end.X, point.Y + end.Y})
+	}
+
+	return convexHull(points)
+}
+
+// strokeArcPolygons sweeps a shape along an arc.  A circle sweeps into a ring sector (which becomes a pie slice when the
+// circle is wider than the arc's radius) with a disc at each end, which is exact up to the chord tolerance.  Any other
+// convex shape is swept along each chord of the arc in turn
+func strokeArcPolygons(shape Polygon, radius float64, isCircle bool, start Point, center Point, sweepAngle float64, tolerance float64) []Polygon {
+	arcRadius := math.Hypot(start.X-center.X, start.Y-center.Y)
+	startAngle := math.Atan2(start.Y-center.Y, start.X-center.X)
+
+	if !isCircle {
+		polygons := make([]Polygon, 0, 10)
+		points := arcPoints(center, arcRadius, startAngle, sweepAngle, tolerance)
+		for i := 1; i < len(points); i++ {
+			polygons = append(polygons, strokeLinearPolygon(shape, radius, false, points[i-1], points[i], tolerance))
+		}
+
+		return polygons
+	}
+
+	// Always build the sector counterclockwise, starting from whichever end of the arc that makes it turn that way
+	if sweepAngle < 0.0 {
+		startAngle += sweepAngle
+		sweepAngle = -sweepAngle
+	}
+
+	outerRadius := arcRadius + radius
+	innerRadius := math.Max(0.0, arcRadius-radius)
+
+	if sweepAngle >= TWO_PI-1e-9 {
+		polygons := []Polygon{circlePolygon(center, outerRadius, tolerance)}
+		if innerRadius > 0.0 {
+			polygons = append(polygons, circlePolygon(center, innerRadius, tolerance).Reverse())
+		}
+
+		return polygons
+	}
+
+	sector := Polygon(arcPoints(center, outerRadius, startAngle, sweepAngle, tolerance))
+	if innerRadius > 0.0 {
+		sector = append(sector, arcPoints(center, innerRadius, startAngle+sweepAngle, -sweepAngle, tolerance)...)
+	} else {
+		sector = append(sector, center)
+	}
+
+	startPoint := Point{center.X + (arcRadius * math.Cos(startAngle)), center.Y + (arcRadius * math.Sin(startAngle))}
+	endPoint := Point{center.X + (arcRadius * math.Cos(startAngle+sweepAngle)), center.Y + (arcRadius * math.Sin(startAngle+sweepAngle))}
+
+	return []Polygon{sector, circlePolygon(startPoint, radius, tolerance), circlePolygon(endPoint, radius, tolerance)}
+}
@@ -0,0 +1,596 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"sort"
+)
+
+type BooleanOperation int
+
+const (
+	UNION_OPERATION BooleanOperation = iota
+	DIFFERENCE_OPERATION
+	INTERSECTION_OPERATION
+	XOR_OPERATION
+)
+
+// Clipping snaps every vertex (including the ones created where edges cross) to a grid this fine, in the same units as
+// the polygons.  For image coordinates in millimetres this is a nanometre, well below any manufacturing precision, while
+// still letting vertices that should coincide be matched exactly
+const CLIPPING_SNAP_PRECISION float64 = 1e-6
+
+// PolygonWithHoles is one connected piece of a flattened shape: a counterclockwise outline, with clockwise holes cut out
+// of it
+type PolygonWithHoles struct {
+	Outline Polygon
+	Holes   []Polygon
+}
+
+// ClipPolygons combines two polygon sets with a boolean operation.  Each set is filled with the nonzero winding rule, like
+// a PolygonLayer, and the result has no overlaps or self intersections
+func ClipPolygons(subject []Polygon, clip []Polygon, operation BooleanOperation) []PolygonWithHoles {
+	var inside func(inSubject bool, inClip bool) bool
+	switch operation {
+	case UNION_OPERATION:
+		inside = func(inSubject bool, inClip bool) bool { return inSubject || inClip }
+
+	case DIFFERENCE_OPERATION:
+		inside = func(inSubject bool, inClip bool) bool { return inSubject && !inClip }
+
+	case INTERSECTION_OPERATION:
+		inside = func(inSubject bool, inClip bool) bool { return inSubject && inClip }
+
+	case XOR_OPERATION:
+		inside = func(inSubject bool, inClip bool) bool { return inSubject != inClip }
+	}
+
+	clipper := newPolygonClipper()
+	clipper.addPolygons(subject, 0)
+	clipper.addPolygons(clip, 1)
+	clipper.splitEdges()
+
+	return clipper.buildResult(inside)
+}
+
+// FlattenLayers works through a stream of dark and clear layers in order, and returns the final shape they leave behind
+func FlattenLayers(layers []PolygonLayer) []PolygonWithHoles {
+	var result []PolygonWithHoles
+	for i := 0; i < len(layers); {
+		// Runs of layers with the same polarity are combined in one operation, which is much faster than clipping each
+		// layer on its own
+		polarity := layers[i].Polarity
+		var run []Polygon
+		for ; i < len(layers) && layers[i].Polarity == polarity; i++ {
+			run = append(run, layers[i].Polygons...)
+		}
+
+		if polarity == DARK_POLARITY {
+			result = ClipPolygons(getPolygonsWithHolesContours(result), run, UNION_OPERATION)
+		} else if len(result) > 0 {
+			result = ClipPolygons(getPolygonsWithHolesContours(result), run, DIFFERENCE_OPERATION)
+		}
+	}
+
+	return result
+}
+
+// Flatten computes the final shape of the image, with every clear polarity object cut out of the dark objects drawn before
+// it.  A negative image starts out dark over its whole bounding box.  Arcs are approximated by chords that never stray
+// more than the tolerance (in millimetres) from the true curve
+func (image *GerberImage) Flatten(tolerance float64) ([]PolygonWithHoles, error) {
+	layers := make([]PolygonLayer, 0, len(image.graphicsObjects)+1)
+	if image.imagePolarity == CLEAR_POLARITY && image.bounds != nil && image.bounds.boundsSet {
+		bounds := image.bounds
+		background := Polygon{
+			{bounds.xMin, bounds.yMin},
+			{bounds.xMax, bounds.yMin},
+			{bounds.xMax, bounds.yMax},
+			{bounds.xMin, bounds.yMax},
+		}
+		layers = append(layers, PolygonLayer{[]Polygon{background}, DARK_POLARITY})
+	}
+
+	for _, graphicsObject := range image.graphicsObjects {
+		polygons, err := graphicsObject.getShapePolygons(tolerance)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, PolygonLayer{polygons, graphicsObject.polarity})
+	}
+
+	return FlattenLayers(layers), nil
+}
+
+// getShapePolygons returns the final shape of a graphics object, ready to be drawn with the object's own polarity.  Most
+// objects are a single dark layer, but macro apertures can erase parts of themselves, which has to be resolved first
+func (graphicsObject *GraphicsObject) getShapePolygons(tolerance float64) ([]Polygon, error) {
+	objectLayers, err := graphicsObject.GetPolygons(tolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objectLayers) == 1 && objectLayers[0].Polarity == DARK_POLARITY {
+		return objectLayers[0].Polygons, nil
+	}
+
+	return getPolygonsWithHolesContours(FlattenLayers(objectLayers)), nil
+}
+
+// GetContours returns the outline followed by the holes
+func (polygon *PolygonWithHoles) GetContours() []Polygon {
+	return append([]Polygon{polygon.Outline}, polygon.Holes...)
+}
+
+func getPolygonsWithHolesContours(polygons []PolygonWithHoles) []Polygon {
+	contours := make([]Polygon, 0, len(polygons))
+	for i := range polygons {
+		contours = append(contours, polygons[i].GetContours()...)
+	}
+
+	return contours
+}
+
+// Contains reports whether a point lies inside the polygon (and not in one of its holes)
+func (polygon *PolygonWithHoles) Contains(point Point) bool {
+	if !polygon.Outline.containsPoint(point) {
+		return false
+	}
+
+	for _, hole := range polygon.Holes {
+		if hole.containsPoint(point) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsPoint is an even-odd point in polygon test, which ignores the polygon's orientation
+func (polygon Polygon) containsPoint(point Point) bool {
+	inside := false
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		if (a.Y > point.Y) != (b.Y > point.Y) {
+			crossingX := a.X + ((point.Y-a.Y)/(b.Y-a.Y))*(b.X-a.X)
+			if point.X < crossingX {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+type clipEdge struct {
+	start   Point
+	end     Point
+	operand int
+	splits  []Point
+}
+
+// clipBundle collects every edge that runs between the same two points, whichever direction they run in and whichever
+// operand they come from.  The winding contributions are counted for crossing the bundle from below to above, or from
+// left to right for vertical bundles
+type clipBundle struct {
+	low          Point
+	high         Point
+	contribution [2]int
+}
+
+type polygonClipper struct {
+	edges   []*clipEdge
+	bundles map[[2]Point]*clipBundle
+}
+
+func newPolygonClipper() *polygonClipper {
+	clipper := new(polygonClipper)
+	clipper.bundles = make(map[[2]Point]*clipBundle)
+
+	return clipper
+}
+
+func snapPoint(point Point) Point {
+	return Point{
+		math.Round(point.X/CLIPPING_SNAP_PRECISION) * CLIPPING_SNAP_PRECISION,
+		math.Round(point.Y/CLIPPING_SNAP_PRECISION) * CLIPPING_SNAP_PRECISION,
+	}
+}
+
+func (clipper *polygonClipper) addPolygons(polygons []Polygon, operand int) {
+	for _, polygon := range polygons {
+		for i := range polygon {
+			start := snapPoint(polygon[i])
+			end := snapPoint(polygon[(i+1)%len(polygon)])
+			if start != end {
+				clipper.edges = append(clipper.edges, &clipEdge{start: start, end: end, operand: operand})
+			}
+		}
+	}
+}
+
+// splitEdges finds every point where two edges cross or touch, and splits the edges there, so that afterwards edges only
+// meet at their end points.  Edges are bucketed into a grid first, so only edges that are close to each other are compared
+func (clipper *polygonClipper) splitEdges() {
+	if len(clipper.edges) == 0 {
+		return
+	}
+
+	xMin, xMax, yMin, yMax := math.MaxFloat64, -math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64
+	for _, edge := range clipper.edges {
+		xMin = math.Min(xMin, math.Min(edge.start.X, edge.end.X))
+		xMax = math.Max(xMax, math.Max(edge.start.X, edge.end.X))
+		yMin = math.Min(yMin, math.Min(edge.start.Y, edge.end.Y))
+		yMax = math.Max(yMax, math.Max(edge.start.Y, edge.end.Y))
+	}
+
+	gridSize := int(math.Min(512.0, math.Ceil(math.Sqrt(float64(len(clipper.edges))))))
+	cellWidth := math.Max((xMax-xMin)/float64(gridSize), CLIPPING_SNAP_PRECISION)
+	cellHeight := math.Max((yMax-yMin)/float64(gridSize), CLIPPING_SNAP_PRECISION)
+	cell := func(value float64, minimum float64, size float64) int {
+		return int(math.Min(float64(gridSize-1), math.Floor((value-minimum)/size)))
+	}
+
+	grid := make(map[[2]int][]int)
+	for i, edge := range clipper.edges {
+		for x := cell(math.Min(edge.start.X, edge.end.X), xMin, cellWidth); x <= cell(math.Max(edge.start.X, edge.end.X), xMin, cellWidth); x++ {
+			for y := cell(math.Min(edge.start.Y, edge.end.Y), yMin, cellHeight); y <= cell(math.Max(edge.start.Y, edge.end.Y), yMin, cellHeight); y++ {
+				grid[[2]int{x, y}] = append(grid[[2]int{x, y}], i)
+			}
+		}
+	}
+
+	// Edges that share several cells would otherwise be compared once for each of them
+	compared := make(map[[2]int]bool)
+	for _, cellEdges := range grid {
+		for i := 0; i < len(cellEdges); i++ {
+			for j := i + 1; j < len(cellEdges); j++ {
+				pair := [2]int{cellEdges[i], cellEdges[j]}
+				if compared[pair] {
+					continue
+				}
+				compared[pair] = true
+				intersectEdges(clipper.edges[pair[0]], clipper.edges[pair[1]])
+			}
+		}
+	}
+}
+
+// intersectEdges records where two edges meet on each of them, if it's somewhere other than their existing end points
+func intersectEdges(a *clipEdge, b *clipEdge) {
+	if math.Max(a.start.X, a.end.X) < math.Min(b.start.X, b.end.X) || math.Max(b.start.X, b.end.X) < math.Min(a.start.X, a.end.X) ||
+		math.Max(a.start.Y, a.end.Y) < math.Min(b.start.Y, b.end.Y) || math.Max(b.start.Y, b.end.Y) < math.Min(a.start.Y, a.end.Y) {
+		return
+	}
+
+	aDX, aDY := a.end.X-a.start.X, a.end.Y-a.start.Y
+	bDX, bDY := b.end.X-b.start.X, b.end.Y-b.start.Y
+	offsetX, offsetY := b.start.X-a.start.X, b.start.Y-a.start.Y
+	denominator := (aDX * bDY) - (aDY * bDX)
+	aLength := math.Hypot(aDX, aDY)
+	bLength := math.Hypot(bDX, bDY)
+
+	if math.Abs(denominator) <= CLIPPING_SNAP_PRECISION*aLength*bLength*1e-3 {
+		// The edges are parallel, so they only meet if they're on the same line, in which case each edge is split where
+		// the other one ends
+		if math.Abs((offsetX*aDY)-(offsetY*aDX)) > CLIPPING_SNAP_PRECISION*aLength {
+			return
+		}
+		a.addSplit(b.start)
+		a.addSplit(b.end)
+		b.addSplit(a.start)
+		b.addSplit(a.end)
+		return
+	}
+
+	t := ((offsetX * bDY) - (offsetY * bDX)) / denominator
+	u := ((offsetX * aDY) - (offsetY * aDX)) / denominator
+	aMargin := CLIPPING_SNAP_PRECISION / aLength
+	bMargin := CLIPPING_SNAP_PRECISION / bLength
+	if t < -aMargin || t > 1.0+aMargin || u < -bMargin || u > 1.0+bMargin {
+		return
+	}
+
+	intersection := snapPoint(Point{a.start.X + (t * aDX), a.start.Y + (t * aDY)})
+	a.addSplit(intersection)
+	b.addSplit(intersection)
+}
+
+// addSplit records a point the edge needs splitting at, as long as it lies within the edge
+func (edge *clipEdge) addSplit(point Point) {
+	if point == edge.start || point == edge.end {
+		return
+	}
+
+	dX, dY := edge.end.X-edge.start.X, edge.end.Y-edge.start.Y
+	lengthSquared := (dX * dX) + (dY * dY)
+	t := (((point.X - edge.start.X) * dX) + ((point.Y - edge.start.Y) * dY)) / lengthSquared
+	distance := math.Abs(((point.X-edge.start.X)*dY)-((point.Y-edge.start.Y)*dX)) / math.Sqrt(lengthSquared)
+	if t <= 0.0 || t >= 1.0 || distance > 2.0*CLIPPING_SNAP_PRECISION {
+		return
+	}
+
+	edge.splits = append(edge.splits, point)
+}
+
+// bundleEdges cuts every edge into pieces at its split points, and gathers the pieces into bundles
+func (clipper *polygonClipper) bundleEdges() {
+	for _, edge := range clipper.edges {
+		dX, dY := edge.end.X-edge.start.X, edge.end.Y-edge.start.Y
+		sort.Slice(edge.splits, func(i int, j int) bool {
+			return ((edge.splits[i].X-edge.start.X)*dX)+((edge.splits[i].Y-edge.start.Y)*dY) <
+				((edge.splits[j].X-edge.start.X)*dX)+((edge.splits[j].Y-edge.start.Y)*dY)
+		})
+
+		previous := edge.start
+		for _, point := range append(edge.splits, edge.end) {
+			if point != previous {
+				clipper.addToBundle(previous, point, edge.operand)
+			}
+			previous = point
+		}
+	}
+}
+
+func (clipper *polygonClipper) addToBundle(start Point, end Point, operand int) {
+	low, high := start, end
+	if (end.X < start.X) || (end.X == start.X && end.Y < start.Y) {
+		low, high = end, start
+	}
+
+	key := [2]Point{low, high}
+	bundle, found := clipper.bundles[key]
+	if !found {
+		bundle = &clipBundle{low: low, high: high}
+		clipper.bundles[key] = bundle
+	}
+
+	// Nonzero winding counts go up by one crossing a counterclockwise contour from outside to inside.  The inside of a
+	// contour is on the left of its edges, so that's crossing an edge running to the right from below, or crossing an
+	// edge running downwards from the left
+	if low.X != high.X {
+		if start == low {
+			bundle.contribution[operand]++
+		} else {
+			bundle.contribution[operand]--
+		}
+	} else {
+		if start == high {
+			bundle.contribution[operand]++
+		} else {
+			bundle.contribution[operand]--
+		}
+	}
+}
+
+func (bundle *clipBundle) isVertical() bool {
+	return bundle.low.X == bundle.high.X
+}
+
+func (bundle *clipBundle) isHorizontal() bool {
+	return bundle.low.Y == bundle.high.Y
+}
+
+// buildResult keeps the bundles that have the result inside on one side and outside on the other, and joins them up into
+// contours
+func (clipper *polygonClipper) buildResult(inside func(inSubject bool, inClip bool) bool) []PolygonWithHoles {
+	clipper.bundleEdges()
+
+	bundles := make([]*clipBundle, 0, len(clipper.bundles))
+	for _, bundle := range clipper.bundles {
+		if bundle.contribution != [2]int{0, 0} {
+			bundles = append(bundles, bundle)
+		}
+	}
+	if len(bundles) == 0 {
+		return nil
+	}
+
+	// The winding numbers next to each bundle are found by casting a ray from its midpoint, down for most bundles and
+	// left for vertical ones, and adding up the bundles it crosses.  The bundles are indexed into strips, so only the
+	// ones in the ray's path need checking
+	xMin, xMax, yMin, yMax := math.MaxFloat64, -math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64
+	for _, bundle := range bundles {
+		xMin = math.Min(xMin, bundle.low.X)
+		xMax = math.Max(xMax, bundle.high.X)
+		yMin = math.Min(yMin, math.Min(bundle.low.Y, bundle.high.Y))
+		yMax = math.Max(yMax, math.Max(bundle.low.Y, bundle.high.Y))
+	}
+
+	stripCount := int(math.Min(1024.0, math.Ceil(math.Sqrt(float64(len(bundles))))))
+	stripWidth := math.Max((xMax-xMin)/float64(stripCount), CLIPPING_SNAP_PRECISION)
+	stripHeight := math.Max((yMax-yMin)/float64(stripCount), CLIPPING_SNAP_PRECISION)
+	strip := func(value float64, minimum float64, size float64) int {
+		return int(math.Max(0.0, math.Min(float64(stripCount-1), math.Floor((value-minimum)/size))))
+	}
+
+	columns := make([][]*clipBundle, stripCount)
+	rows := make([][]*clipBundle, stripCount)
+	for _, bundle := range bundles {
+		if !bundle.isVertical() {
+			for i := strip(bundle.low.X, xMin, stripWidth); i <= strip(bundle.high.X, xMin, stripWidth); i++ {
+				columns[i] = append(columns[i], bundle)
+			}
+		}
+		if !bundle.isHorizontal() {
+			lowY, highY := math.Min(bundle.low.Y, bundle.high.Y), math.Max(bundle.low.Y, bundle.high.Y)
+			for i := strip(lowY, yMin, stripHeight); i <= strip(highY, yMin, stripHeight); i++ {
+				rows[i] = append(rows[i], bundle)
+			}
+		}
+	}
+
+	var resultEdges [][2]Point
+	for _, bundle := range bundles {
+		midpoint := Point{(bundle.low.X + bundle.high.X) / 2.0, (bundle.low.Y + bundle.high.Y) / 2.0}
+		var winding [2]int
+
+		if !bundle.isVertical() {
+			for _, other := range columns[strip(midpoint.X, xMin, stripWidth)] {
+				// Half open ranges make sure a ray through a vertex only counts one of the bundles meeting there
+				if other == bundle || midpoint.X < other.low.X || midpoint.X >= other.high.X {
+					continue
+				}
+				otherY := other.low.Y + ((midpoint.X-other.low.X)/(other.high.X-other.low.X))*(other.high.Y-other.low.Y)
+				if otherY < midpoint.Y {
+					winding[0] += other.contribution[0]
+					winding[1] += other.contribution[1]
+				}
+			}
+		} else {
+			for _, other := range rows[strip(midpoint.Y, yMin, stripHeight)] {
+				// Only the contributions for crossing left to right are kept for vertical bundles, so non-vertical bundles'
+				// contributions are converted by the direction they cross the ray in
+				lowY, highY := math.Min(other.low.Y, other.high.Y), math.Max(other.low.Y, other.high.Y)
+				if other == bundle || midpoint.Y < lowY || midpoint.Y >= highY {
+					continue
+				}
+				otherX := other.low.X + ((midpoint.Y-other.low.Y)/(other.high.Y-other.low.Y))*(other.high.X-other.low.X)
+				if otherX < midpoint.X {
+					sign := 1
+					if !other.isVertical() && other.high.Y > other.low.Y {
+						sign = -1
+					}
+					winding[0] += sign * other.contribution[0]
+					winding[1] += sign * other.contribution[1]
+				}
+			}
+		}
+
+		insideBefore := inside(winding[0] != 0, winding[1] != 0)
+		insideAfter := inside(winding[0]+bundle.contribution[0] != 0, winding[1]+bundle.contribution[1] != 0)
+		if insideBefore == insideAfter {
+			continue
+		}
+
+		// Result edges keep the inside on their left: running right with the inside above, or down with the inside on the right
+		if bundle.isVertical() == insideAfter {
+			resultEdges = append(resultEdges, [2]Point{bundle.high, bundle.low})
+		} else {
+			resultEdges = append(resultEdges, [2]Point{bundle.low, bundle.high})
+		}
+	}
+
+	return assembleContours(resultEdges)
+}
+
+// assembleContours joins result edges into closed contours, then sorts the holes into the outlines that contain them
+func assembleContours(edges [][2]Point) []PolygonWithHoles {
+	outgoing := make(map[Point][]int)
+	for i, edge := range edges {
+		outgoing[edge[0]] = append(outgoing[edge[0]], i)
+	}
+
+	used := make([]bool, len(edges))
+	var outlines, holes []Polygon
+	for first := range edges {
+		if used[first] {
+			continue
+		}
+
+		used[first] = true
+		contour := Polygon{edges[first][0]}
+		previous, current := edges[first][0], edges[first][1]
+		closed := false
+		for {
+			if current == contour[0] {
+				closed = true
+				break
+			}
+			contour = append(contour, current)
+
+			// Where several contours touch at a vertex, take the first edge clockwise from the one we arrived on, which keeps
+			// each contour from crossing over the others
+			backAngle := math.Atan2(previous.Y-current.Y, previous.X-current.X)
+			next, bestTurn := -1, math.MaxFloat64
+			for _, candidate := range outgoing[current] {
+				if used[candidate] {
+					continue
+				}
+				turn := backAngle - math.Atan2(edges[candidate][1].Y-current.Y, edges[candidate][1].X-current.X)
+				for turn <= 0.0 {
+					turn += TWO_PI
+				}
+				if turn < bestTurn {
+					next, bestTurn = candidate, turn
+				}
+			}
+
+			if next < 0 {
+				// A dead end can only come from rounding, and the partial contour is dropped
+				break
+			}
+			used[next] = true
+			previous, current = current, edges[next][1]
+		}
+
+		if !closed {
+			continue
+		}
+
+		contour = removeCollinearPoints(contour)
+		if len(contour) < 3 {
+			continue
+		}
+
+		if area := contour.Area(); area > CLIPPING_SNAP_PRECISION*CLIPPING_SNAP_PRECISION {
+			outlines = append(outlines, contour)
+		} else if area < -CLIPPING_SNAP_PRECISION*CLIPPING_SNAP_PRECISION {
+			holes = append(holes, contour)
+		}
+	}
+
+	result := make([]PolygonWithHoles, len(outlines))
+	outlineAreas := make([]float64, len(outlines))
+	for i, outline := range outlines {
+		result[i].Outline = outline
+		outlineAreas[i] = outline.Area()
+	}
+
+	// Each hole belongs to the smallest outline around it.  Holes can touch their outline, so the test uses the middle of
+	// the hole's first edge rather than a vertex
+	for _, hole := range holes {
+		testPoint := Point{(hole[0].X + hole[1].X) / 2.0, (hole[0].Y + hole[1].Y) / 2.0}
+		owner := -1
+		for i, outline := range outlines {
+			if (owner < 0 || outlineAreas[i] < outlineAreas[owner]) && outlineAreas[i] > -hole.Area() && outline.containsPoint(testPoint) {
+				owner = i
+			}
+		}
+		if owner >= 0 {
+			result[owner].Holes = append(result[owner].Holes, hole)
+		}
+	}
+
+	return result
+}
+
+// removeCollinearPoints drops vertices that sit on the straight line between their neighbours, which splitting edges
+// leaves behind
+func removeCollinearPoints(contour Polygon) Polygon {
+	for changed := true; changed && len(contour) >= 3; {
+		changed = false
+		simplified := make(Polygon, 0, len(contour))
+		for i := range contour {
+			previous := contour[(i+len(contour)-1)%len(contour)]
+			next := contour[(i+1)%len(contour)]
+			if len(simplified) > 0 {
+				previous = simplified[len(simplified)-1]
+			}
+
+			dX, dY := next.X-previous.X, next.Y-previous.Y
+			length := math.Hypot(dX, dY)
+			if length > 0.0 && math.Abs(((contour[i].X-previous.X)*dY)-((contour[i].Y-previous.Y)*dX))/length < CLIPPING_SNAP_PRECISION &&
+				((contour[i].X-previous.X)*dX)+((contour[i].Y-previous.Y)*dY) > 0.0 &&
+				((next.X-contour[i].X)*dX)+((next.Y-contour[i].Y)*dY) > 0.0 {
+				changed = true
+				continue
+			}
+			simplified = append(simplified, contour[i])
+		}
+		contour = simplified
+	}
+
+	return contour
+}
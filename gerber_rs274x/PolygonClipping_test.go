@@ -0,0 +1,236 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"testing"
+)
+
+const clippingTestTolerance = 1e-9
+
+// makeRectangle returns a counterclockwise rectangle
+func makeRectangle(xMin float64, yMin float64, xMax float64, yMax float64) Polygon {
+	return Polygon{{xMin, yMin}, {xMax, yMin}, {xMax, yMax}, {xMin, yMax}}
+}
+
+func getShapesArea(shapes []PolygonWithHoles) float64 {
+	area := 0.0
+	for _, shape := range shapes {
+		area += shape.Outline.Area()
+		for _, hole := range shape.Holes {
+			area += hole.Area()
+		}
+	}
+	return area
+}
+
+// checkShapes checks the number of pieces, holes and vertices and the total area of a clipping result, as well as the
+// orientation of each contour
+func checkShapes(t *testing.T, shapes []PolygonWithHoles, pieces int, holes int, vertices int, area float64) {
+	t.Helper()
+
+	if len(shapes) != pieces {
+		t.Errorf("Got %d pieces, want %d", len(shapes), pieces)
+	}
+
+	holeCount, vertexCount := 0, 0
+	for _, shape := range shapes {
+		if !shape.Outline.IsCounterClockwise() {
+			t.Errorf("Outline %v should be counterclockwise", shape.Outline)
+		}
+		for _, hole := range shape.Holes {
+			if hole.IsCounterClockwise() {
+				t.Errorf("Hole %v should be clockwise", hole)
+			}
+		}
+		holeCount += len(shape.Holes)
+		for _, contour := range shape.GetContours() {
+			vertexCount += len(contour)
+		}
+	}
+
+	if holeCount != holes {
+		t.Errorf("Got %d holes, want %d", holeCount, holes)
+	}
+	if vertexCount != vertices {
+		t.Errorf("Got %d vertices, want %d", vertexCount, vertices)
+	}
+	if got := getShapesArea(shapes); math.Abs(got-area) > clippingTestTolerance {
+		t.Errorf("Area is %f, want %f", got, area)
+	}
+}
+
+func checkContains(t *testing.T, shapes []PolygonWithHoles, point Point, want bool) {
+	t.Helper()
+
+	got := false
+	for i := range shapes {
+		got = got || shapes[i].Contains(point)
+	}
+	if got != want {
+		t.Errorf("Contains(%v) = %v, want %v", point, got, want)
+	}
+}
+
+func TestClipPolygonsOperations(t *testing.T) {
+	a := []Polygon{makeRectangle(0, 0, 2, 2)}
+	b := []Polygon{makeRectangle(1, 1, 3, 3)}
+
+	t.Run("Union", func(t *testing.T) {
+		checkShapes(t, ClipPolygons(a, b, UNION_OPERATION), 1, 0, 8, 7.0)
+	})
+	t.Run("Difference", func(t *testing.T) {
+		result := ClipPolygons(a, b, DIFFERENCE_OPERATION)
+		checkShapes(t, result, 1, 0, 6, 3.0)
+		checkContains(t, result, Point{0.5, 0.5}, true)
+		checkContains(t, result, Point{1.5, 1.5}, false)
+	})
+	t.Run("Intersection", func(t *testing.T) {
+		checkShapes(t, ClipPolygons(a, b, INTERSECTION_OPERATION), 1, 0, 4, 1.0)
+	})
+	t.Run("Xor", func(t *testing.T) {
+		result := ClipPolygons(a, b, XOR_OPERATION)
+		checkShapes(t, result, 2, 0, 12, 6.0)
+		checkContains(t, result, Point{1.5, 1.5}, false)
+		checkContains(t, result, Point{2.5, 2.5}, true)
+	})
+}
+
+func TestClipPolygonsEmpty(t *testing.T) {
+	a := []Polygon{makeRectangle(0, 0, 1, 1)}
+
+	if result := ClipPolygons(nil, nil, UNION_OPERATION); len(result) != 0 {
+		t.Errorf("Union of nothing gave %v", result)
+	}
+	if result := ClipPolygons(nil, a, DIFFERENCE_OPERATION); len(result) != 0 {
+		t.Errorf("Difference from nothing gave %v", result)
+	}
+	checkShapes(t, ClipPolygons(a, nil, UNION_OPERATION), 1, 0, 4, 1.0)
+	checkShapes(t, ClipPolygons(a, nil, DIFFERENCE_OPERATION), 1, 0, 4, 1.0)
+}
+
+func TestClipPolygonsTouchingEdges(t *testing.T) {
+	// Side by side squares sharing a whole edge merge into one rectangle, without the points along the shared edge
+	result := ClipPolygons([]Polygon{makeRectangle(0, 0, 1, 1)}, []Polygon{makeRectangle(1, 0, 2, 1)}, UNION_OPERATION)
+	checkShapes(t, result, 1, 0, 4, 2.0)
+
+	// Sharing part of an edge leaves a step in the outline
+	result = ClipPolygons([]Polygon{makeRectangle(0, 0, 1, 1)}, []Polygon{makeRectangle(1, 0.5, 2, 2)}, UNION_OPERATION)
+	checkShapes(t, result, 1, 0, 8, 2.5)
+
+	// Squares that only touch at a corner stay apart
+	result = ClipPolygons([]Polygon{makeRectangle(0, 0, 1, 1)}, []Polygon{makeRectangle(1, 1, 2, 2)}, UNION_OPERATION)
+	checkShapes(t, result, 2, 0, 8, 2.0)
+
+	// Taking away a square that only touches changes nothing
+	result = ClipPolygons([]Polygon{makeRectangle(0, 0, 1, 1)}, []Polygon{makeRectangle(1, 0, 2, 1)}, DIFFERENCE_OPERATION)
+	checkShapes(t, result, 1, 0, 4, 1.0)
+}
+
+func TestClipPolygonsCoincidentEdges(t *testing.T) {
+	square := []Polygon{makeRectangle(0, 0, 1, 1)}
+
+	checkShapes(t, ClipPolygons(square, square, UNION_OPERATION), 1, 0, 4, 1.0)
+	checkShapes(t, ClipPolygons(square, square, INTERSECTION_OPERATION), 1, 0, 4, 1.0)
+	if result := ClipPolygons(square, square, DIFFERENCE_OPERATION); len(result) != 0 {
+		t.Errorf("Square less itself gave %v", result)
+	}
+
+	// The same square drawn clockwise still counts as filled under the nonzero rule
+	checkShapes(t, ClipPolygons(square, []Polygon{square[0].Reverse()}, UNION_OPERATION), 1, 0, 4, 1.0)
+
+	// An L shape whose arms overlap along the axes
+	result := ClipPolygons([]Polygon{makeRectangle(0, 0, 2, 1)}, []Polygon{makeRectangle(0, 0, 1, 2)}, UNION_OPERATION)
+	checkShapes(t, result, 1, 0, 6, 3.0)
+
+	// Overlapping edges within one operand
+	result = ClipPolygons([]Polygon{makeRectangle(0, 0, 2, 1), makeRectangle(1, 0, 3, 1)}, nil, UNION_OPERATION)
+	checkShapes(t, result, 1, 0, 4, 3.0)
+}
+
+func TestClipPolygonsHoles(t *testing.T) {
+	// A clockwise contour inside a counterclockwise one is a hole under the nonzero rule
+	frame := []Polygon{makeRectangle(0, 0, 4, 4), makeRectangle(1, 1, 3, 3).Reverse()}
+	result := ClipPolygons(frame, nil, UNION_OPERATION)
+	checkShapes(t, result, 1, 1, 8, 12.0)
+	checkContains(t, result, Point{2, 2}, false)
+	checkContains(t, result, Point{0.5, 2}, true)
+
+	// Filling the hole in
+	result = ClipPolygons(frame, []Polygon{makeRectangle(1, 1, 3, 3)}, UNION_OPERATION)
+	checkShapes(t, result, 1, 0, 4, 16.0)
+
+	// An island in the hole is a piece of its own
+	result = ClipPolygons(frame, []Polygon{makeRectangle(1.5, 1.5, 2.5, 2.5)}, UNION_OPERATION)
+	checkShapes(t, result, 2, 1, 12, 13.0)
+	checkContains(t, result, Point{2, 2}, true)
+	checkContains(t, result, Point{1.25, 2}, false)
+
+	// Holes in two separate pieces go to the right outline
+	result = ClipPolygons([]Polygon{makeRectangle(0, 0, 4, 4), makeRectangle(10, 0, 14, 4)},
+		[]Polygon{makeRectangle(1, 1, 2, 2), makeRectangle(11, 1, 13, 3)}, DIFFERENCE_OPERATION)
+	checkShapes(t, result, 2, 2, 16, 27.0)
+	for _, shape := range result {
+		if len(shape.Holes) != 1 || !shape.Outline.containsPoint(shape.Holes[0][0]) {
+			t.Errorf("Piece %v should have one hole inside it, got %v", shape.Outline, shape.Holes)
+		}
+	}
+
+	// A triangle cut out of the square, touching its edge at one corner
+	result = ClipPolygons([]Polygon{makeRectangle(0, 0, 4, 4)}, []Polygon{{{0, 2}, {2, 1}, {2, 3}}}, DIFFERENCE_OPERATION)
+	if area := getShapesArea(result); math.Abs(area-14.0) > clippingTestTolerance {
+		t.Errorf("Area is %f, want 14", area)
+	}
+	checkContains(t, result, Point{1.5, 2}, false)
+	checkContains(t, result, Point{0.5, 1}, true)
+	checkContains(t, result, Point{3, 2}, true)
+}
+
+func TestClipPolygonsSnapRounding(t *testing.T) {
+	// Vertices closer together than the snap precision are the same vertex, so the squares share an edge
+	result := ClipPolygons([]Polygon{makeRectangle(0, 0, 1, 1)}, []Polygon{makeRectangle(1+1e-8, 0, 2, 1-1e-8)},
+		UNION_OPERATION)
+	checkShapes(t, result, 1, 0, 4, 2.0)
+
+	// Edges that nearly coincide, running opposite ways, cancel out rather than leaving a sliver
+	result = ClipPolygons([]Polygon{makeRectangle(0, 0, 1, 1)}, []Polygon{makeRectangle(0, 1-1e-9, 1, 2)}, UNION_OPERATION)
+	checkShapes(t, result, 1, 0, 4, 2.0)
+
+	// A vertex that pokes into an edge by less than the snap precision only touches it, so the shapes stay apart
+	triangle := Polygon{{1, 1 - 1e-8}, {1.5, 2}, {0.5, 2}}
+	result = ClipPolygons([]Polygon{makeRectangle(0, 0, 2, 1)}, []Polygon{triangle}, UNION_OPERATION)
+	checkShapes(t, result, 2, 0, 7, 2.5)
+
+	// Where edges cross at an awkward point, the new vertex is snapped to the grid, and so is everything else
+	result = ClipPolygons([]Polygon{{{0, 0}, {3, 1}, {0, 1}}}, []Polygon{{{1, 0}, {2, 0}, {1.5, 0.9}}}, UNION_OPERATION)
+	for _, shape := range result {
+		for _, contour := range shape.GetContours() {
+			for _, point := range contour {
+				if snapPoint(point) != point {
+					t.Errorf("Vertex %v isn't on the snap grid", point)
+				}
+			}
+		}
+	}
+	if len(result) != 1 {
+		t.Errorf("Got %d pieces, want 1", len(result))
+	}
+}
+
+func TestFlattenLayers(t *testing.T) {
+	layers := []PolygonLayer{
+		{[]Polygon{makeRectangle(0, 0, 3, 3)}, DARK_POLARITY},
+		{[]Polygon{makeRectangle(1, 1, 2, 2)}, CLEAR_POLARITY},
+		{[]Polygon{makeRectangle(1.25, 1.25, 1.75, 1.75)}, DARK_POLARITY},
+	}
+	result := FlattenLayers(layers)
+	checkShapes(t, result, 2, 1, 12, 8.25)
+	checkContains(t, result, Point{1.5, 1.5}, true)
+	checkContains(t, result, Point{1.1, 1.5}, false)
+
+	// Clearing before anything has been drawn does nothing
+	result = FlattenLayers([]PolygonLayer{
+		{[]Polygon{makeRectangle(0, 0, 1, 1)}, CLEAR_POLARITY},
+		{[]Polygon{makeRectangle(0, 0, 1, 1)}, DARK_POLARITY},
+	})
+	checkShapes(t, result, 1, 0, 4, 1.0)
+}
@@ -0,0 +1,39 @@
+package gerber_rs274x
+
+import "fmt"
+
+// OffsetPolygons grows a flattened shape outward by a distance, giving the area a circle of that radius covers as its
+// center moves over the shape.  It's built as the union of the shape with a round ended stroke along every edge of every
+// contour, which rounds off convex corners and shrinks holes, exactly like a round tool would.  A negative distance
+// shrinks the shape instead, by cutting the strokes away, leaving the area the center of a circle can reach while the
+// circle stays inside.  Arcs are approximated by chords that never stray more than the tolerance from the true curve
+func OffsetPolygons(polygons []PolygonWithHoles, distance float64, tolerance float64) ([]PolygonWithHoles, error) {
+	if tolerance <= 0.0 {
+		return nil, fmt.Errorf("Chord tolerance must be positive, got %f", tolerance)
+	}
+
+	contours := getPolygonsWithHolesContours(polygons)
+	if distance == 0.0 {
+		return ClipPolygons(contours, nil, UNION_OPERATION), nil
+	}
+
+	radius := distance
+	operation := UNION_OPERATION
+	if distance < 0.0 {
+		radius = -distance
+		operation = DIFFERENCE_OPERATION
+	}
+
+	// Every stroke overlaps the strokes of all the edges within its radius, so long runs of short edges make for a lot of
+	// overlapping strokes to clip.  Simplifying the contours first keeps that in check, and stops the vertex count growing
+	// when offsets are repeated
+	strokes := make([]Polygon, 0, 10)
+	for _, contour := range contours {
+		contour = contour.simplify(tolerance / 2.0)
+		for i := range contour {
+			strokes = append(strokes, strokeLinearPolygon(nil, radius, true, contour[i], contour[(i+1)%len(contour)], tolerance))
+		}
+	}
+
+	return ClipPolygons(contours, strokes, operation), nil
+}
@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type PolygonPrimitive struct {
@@ -30,9 +29,16 @@ func (primitive *PolygonPrimitive) GetPrimitiveBounds(env *ExpressionEnvironment
 	return centerX - radius, centerX + radius, centerY - radius, centerY + radius
 }
 
-func (primitive *PolygonPrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
-	return nil
+func (primitive *PolygonPrimitive) getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error) {
+	nVertices := int(primitive.nVertices.EvaluateExpression(env))
+	if nVertices < 3 {
+		return PolygonLayer{}, fmt.Errorf("Polygon primitive needs at least 3 vertices, got %d", nVertices)
+	}
+
+	center := Point{primitive.centerX.EvaluateExpression(env), primitive.centerY.EvaluateExpression(env)}
+	polygon := regularPolygon(center, primitive.diameter.EvaluateExpression(env), nVertices, 0.0)
+
+	return newPrimitiveLayer([]Polygon{polygon}, primitive.exposure.EvaluateExpression(env), primitive.rotationAngle.EvaluateExpression(env)), nil
 }
 
 func (primitive *PolygonPrimitive) String() string {
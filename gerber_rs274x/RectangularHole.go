@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type RectangularHole struct {
@@ -14,27 +13,13 @@ func (rectangle *RectangularHole) HolePlaceholder() {
 
 }
 
-func (rectangle *RectangularHole) String() string {
-	return fmt.Sprintf("{RH, X: %f, Y: %f}", rectangle.holeXSize, rectangle.holeYSize)
-}
-
-func (hole *RectangularHole) DrawHoleSurface(surface *cairo.Surface) error {
-
+func (hole *RectangularHole) getHolePolygon(tolerance float64) Polygon {
 	xRadius := hole.holeXSize / 2.0
 	yRadius := hole.holeYSize / 2.0
 
-	surface.Save()
-
-	// We temporarily set the compositing operator to clear, to clear the hole to transparent
-	surface.SetOperator(cairo.OPERATOR_CLEAR)
-	surface.MoveTo(-xRadius, -yRadius)
-	surface.LineTo(xRadius, -yRadius)
-	surface.LineTo(xRadius, yRadius)
-	surface.LineTo(-xRadius, yRadius)
-	surface.LineTo(-xRadius, -yRadius)
-	surface.Fill()
-
-	surface.Restore()
+	return rectanglePolygon(-xRadius, xRadius, -yRadius, yRadius).Reverse()
+}
 
-	return nil
+func (rectangle *RectangularHole) String() string {
+	return fmt.Sprintf("{RH, X: %f, Y: %f}", rectangle.holeXSize, rectangle.holeYSize)
 }
@@ -2,11 +2,10 @@ package gerber_rs274x
 
 import (
 	"fmt"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type SetCurrentAperture struct {
+	dataBlockPosition
 	apertureNumber int
 }
 
@@ -26,22 +25,8 @@ func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockBoundsCheck(imageB
 	return nil
 }
 
-func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockToolpath(camo *CamOutput, gfxState *GraphicsState) error {
-	setCurrentAperture.ProcessDataBlockSurface(nil, gfxState)
-
-	return nil
-}
-
-func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	// Make sure the aperture we're trying to switch to has already been defined
-	if _, exists := gfxState.apertures[setCurrentAperture.apertureNumber]; !exists {
-		return fmt.Errorf("Unable to switch to undefined aperture %d", setCurrentAperture.apertureNumber)
-	}
-
-	gfxState.currentAperture = setCurrentAperture.apertureNumber
-	gfxState.apertureSet = true
-
-	return nil
+func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
+	return setCurrentAperture.ProcessDataBlockBoundsCheck(nil, gfxState)
 }
 
 func (setCurrentAperture *SetCurrentAperture) String() string {
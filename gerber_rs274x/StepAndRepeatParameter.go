@@ -2,11 +2,10 @@ package gerber_rs274x
 
 import (
 	"fmt"
-
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type StepAndRepeatParameter struct {
+	dataBlockPosition
 	paramCode     ParameterCode
 	xRepeats      int
 	yRepeats      int
@@ -14,23 +13,87 @@ type StepAndRepeatParameter struct {
 	yStepDistance float64
 }
 
-func (stepAndRepeat *StepAndRepeatParameter) ProcessDataBlockToolpath(*CamOutput, *GraphicsState) error {
-	return nil
-}
 func (stepAndRepeat *StepAndRepeatParameter) DataBlockPlaceholder() {
 
 }
 
 func (stepAndRepeat *StepAndRepeatParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
-	//TODO: Implement this
 	return nil
 }
 
-func (stepAndRepeat *StepAndRepeatParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	//TODO: Implement this
+// Step and repeat blocks are buffered and replayed by processDataBlocks, so there's nothing left to do when the parameter
+// itself is processed
+func (stepAndRepeat *StepAndRepeatParameter) ProcessDataBlockInterpret(gfxState *GraphicsState) error {
 	return nil
 }
 
+// processDataBlocks runs one of the processing passes over a parsed file.  The data blocks inside a step and repeat
+// block are buffered until the block is closed (by the next SR parameter, or the end of the file), then replayed once
+// for each copy, with the copy's step offset added to every absolute coordinate
+func processDataBlocks(parsedFile []DataBlock, gfxState *GraphicsState, process func(dataBlock DataBlock) error) error {
+	var openBlock *StepAndRepeatParameter
+	var blockContents []DataBlock
+
+	closeBlock := func() error {
+		if openBlock == nil {
+			return nil
+		}
+
+		// Each copy starts from the current point at the start of the block, moved to the copy's position
+		startX, startY := gfxState.currentX, gfxState.currentY
+		for yRepeat := 0; yRepeat < openBlock.yRepeats; yRepeat++ {
+			for xRepeat := 0; xRepeat < openBlock.xRepeats; xRepeat++ {
+				gfxState.stepOffsetX = float64(xRepeat) * openBlock.xStepDistance
+				gfxState.stepOffsetY = float64(yRepeat) * openBlock.yStepDistance
+				gfxState.updateCurrentCoordinate(startX+gfxState.stepOffsetX, startY+gfxState.stepOffsetY)
+
+				for _, dataBlock := range blockContents {
+					if err := process(dataBlock); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		gfxState.stepOffsetX, gfxState.stepOffsetY = 0.0, 0.0
+		openBlock, blockContents = nil, nil
+
+		return nil
+	}
+
+	for _, dataBlock := range parsedFile {
+		switch dataBlockValue := dataBlock.(type) {
+		case *StepAndRepeatParameter:
+			if err := closeBlock(); err != nil {
+				return err
+			}
+
+			// An SR with a single copy just closes the previous block, without opening a new one
+			if dataBlockValue.xRepeats > 1 || dataBlockValue.yRepeats > 1 {
+				openBlock = dataBlockValue
+			}
+			continue
+
+		case *GraphicsStateChange:
+			// The end of the file closes any open block before it's processed
+			if dataBlockValue.fnCode == END_OF_FILE {
+				if err := closeBlock(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if openBlock != nil {
+			blockContents = append(blockContents, dataBlock)
+		} else if err := process(dataBlock); err != nil {
+			return err
+		}
+	}
+
+	// Files that end without an M02 still get their last block
+	return closeBlock()
+}
+
 func (srParam *StepAndRepeatParameter) String() string {
 	return fmt.Sprintf("{SR, X Repeats: %d, Y Repeats: %d, I Step: %f, J Step: %f}", srParam.xRepeats, srParam.yRepeats, srParam.xStepDistance, srParam.yStepDistance)
 }
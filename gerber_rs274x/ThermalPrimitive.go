@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 	"math"
 )
 
@@ -31,65 +30,37 @@ func (primitive *ThermalPrimitive) GetPrimitiveBounds(env *ExpressionEnvironment
 	return centerX - radius, centerX + radius, centerY - radius, centerY + radius
 }
 
-func (primitive *ThermalPrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	// If there is a rotation angle defined, first check that the center is at the origin
-	// (rotations are only allowed if the center is at the origin)
-	centerX := primitive.centerX.EvaluateExpression(env)
-	centerY := primitive.centerY.EvaluateExpression(env)
-	rotation := primitive.rotationAngle.EvaluateExpression(env) * (math.Pi / 180.0)
+func (primitive *ThermalPrimitive) getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error) {
+	center := Point{primitive.centerX.EvaluateExpression(env), primitive.centerY.EvaluateExpression(env)}
+	outerRadius := primitive.outerDiameter.EvaluateExpression(env) / 2.0
+	innerRadius := primitive.innerDiameter.EvaluateExpression(env) / 2.0
+	halfGapThickness := primitive.gapThickness.EvaluateExpression(env) / 2.0
 
-	if rotation != 0.0 && (centerX != 0.0 || centerY != 0.0) {
-		return fmt.Errorf("Thermal primitive rotation is only allowed if the center is at the origin")
+	if outerRadius <= halfGapThickness {
+		// The gaps cover the whole thermal
+		return newPrimitiveLayer(nil, 1.0, 0.0), nil
 	}
 
-	// Now that we've checked the center, apply the rotation
-	surface.Save()
-	surface.Rotate(rotation)
-
-	surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-
-	// Now, draw the thermal
-	outerRadius := (primitive.outerDiameter.EvaluateExpression(env) / 2.0)
-	innerRadius := (primitive.innerDiameter.EvaluateExpression(env) / 2.0)
-	halfGapThickness := (primitive.gapThickness.EvaluateExpression(env) / 2.0)
-
-	outerStartX := centerX + halfGapThickness
-	outerStartY := centerY + outerRadius
-	outerEndX := centerX + outerRadius
-	outerEndY := centerY + halfGapThickness
-	innerStartX := centerX + innerRadius
-	innerStartY := outerEndY
-	innerEndX := outerStartX
-	innerEndY := centerY + innerRadius
-	outerStartAngle := math.Atan2(outerStartY, outerStartX)
-	outerEndAngle := math.Atan2(outerEndY, outerEndX)
-	innerStartAngle := math.Atan2(innerStartY, innerStartX)
-	innerEndAngle := math.Atan2(innerEndY, innerEndX)
-
-	// Since the thermal is composed of 4 copies of the same shape, just rotated by 90 degrees,
-	// we draw the same shape 4 times, rotating the surface by 90 degrees each time
-
-	for i := 0; i < 4; i++ {
-		surface.Save()
-
-		// Rotate the surface
-		surface.Rotate(ONE_HALF_PI * float64(i))
-
-		//Draw one piece of the primitive
-		surface.MoveTo(outerStartX, outerStartY)
-		surface.ArcNegative(centerX, centerY, outerRadius, outerStartAngle, outerEndAngle)
-		surface.LineTo(innerStartX, innerStartY)
-		surface.Arc(centerX, centerY, innerRadius, innerStartAngle, innerEndAngle)
-		surface.LineTo(outerStartX, outerStartY)
-		surface.Fill()
-
-		surface.Restore()
+	// Build the piece of the thermal in the first quadrant: along the outer circle from the horizontal gap to the vertical
+	// gap, then back along the inner circle (or the corner where the gaps meet, if they're wider than the inner circle)
+	outerStartAngle := math.Asin(halfGapThickness / outerRadius)
+	piece := Polygon(arcPoints(Point{}, outerRadius, outerStartAngle, ONE_HALF_PI-(2.0*outerStartAngle), tolerance))
+	if innerRadius > halfGapThickness {
+		innerStartAngle := math.Asin(halfGapThickness / innerRadius)
+		piece = append(piece, arcPoints(Point{}, innerRadius, ONE_HALF_PI-innerStartAngle, -(ONE_HALF_PI-(2.0*innerStartAngle)), tolerance)...)
+	} else {
+		piece = append(piece, Point{halfGapThickness, halfGapThickness})
 	}
 
-	// Undo all surface transformations
-	surface.Restore()
+	// The thermal is 4 copies of the same piece, rotated by 90 degrees each time
+	polygons := make([]Polygon, 4)
+	for i := range polygons {
+		placement := newRotationTransform(ONE_HALF_PI * float64(i))
+		placement.x0, placement.y0 = center.X, center.Y
+		polygons[i] = piece.Transform(placement)
+	}
 
-	return nil
+	return newPrimitiveLayer(polygons, 1.0, primitive.rotationAngle.EvaluateExpression(env)), nil
 }
 
 func (primitive *ThermalPrimitive) String() string {
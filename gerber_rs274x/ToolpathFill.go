@@ -0,0 +1,110 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// fillPolygons exposes the inside of a set of polygons (filled with the nonzero winding rule) by rastering the laser back
+// and forth across it, in rows one tool width apart.  The polygons are in image coordinates, and each pass is pulled in by
+// half a tool width at both ends, so the edges of the beam stay inside the shape
+func (camo *CamOutput) fillPolygons(polygons []Polygon) {
+	yMin, yMax := math.MaxFloat64, -math.MaxFloat64
+	for _, polygon := range polygons {
+		for _, point := range polygon {
+			yMin = math.Min(yMin, point.Y)
+			yMax = math.Max(yMax, point.Y)
+		}
+	}
+	if yMin > yMax {
+		return
+	}
+
+	tw := camo.toolWidth
+	tw2 := tw / 2.0
+
+	// Rows run through the middle of each tool width strip.  Shapes thinner than the tool still get a single row
+	rows := int(math.Max(1.0, math.Floor((yMax-yMin)/tw)))
+	firstY := ((yMin + yMax) / 2.0) - (float64(rows-1) * tw / 2.0)
+
+	reverse := false
+	for row := 0; row < rows; row++ {
+		y := firstY + (float64(row) * tw)
+		spans := scanlineSpans(polygons, y)
+
+		if reverse {
+			for i, j := 0, len(spans)-1; i < j; i, j = i+1, j-1 {
+				spans[i], spans[j] = spans[j], spans[i]
+			}
+		}
+
+		for _, span := range spans {
+			startX, endX := span[0], span[1]
+			if endX-startX > tw {
+				startX, endX = startX+tw2, endX-tw2
+			} else {
+				startX, endX = (startX+endX)/2.0, (startX+endX)/2.0
+			}
+			if reverse {
+				startX, endX = endX, startX
+			}
+
+			x0, y0 := camo.translateScale(startX, y)
+			x1, y1 := camo.translateScale(endX, y)
+			fmt.Fprintf(camo.wrt, "G00X%fY%f\n", x0, y0)
+			fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
+			fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1, y1, camo.feedrate)
+			fmt.Fprintf(camo.wrt, "M05\n")
+		}
+
+		// Rastering back and forth saves a rapid move back across the shape for every row
+		reverse = !reverse
+	}
+}
+
+// scanlineSpans returns the [start, end] x ranges where a horizontal line at y is inside the polygons, in increasing x
+func scanlineSpans(polygons []Polygon, y float64) [][2]float64 {
+	type crossing struct {
+		x       float64
+		winding int
+	}
+
+	crossings := make([]crossing, 0, 10)
+	for _, polygon := range polygons {
+		for i := range polygon {
+			a := polygon[i]
+			b := polygon[(i+1)%len(polygon)]
+
+			// Half open ranges make sure a line through a vertex only crosses one of the edges meeting there
+			if (a.Y <= y) == (b.Y <= y) {
+				continue
+			}
+
+			winding := 1
+			if b.Y < a.Y {
+				winding = -1
+			}
+			crossings = append(crossings, crossing{a.X + ((y-a.Y)/(b.Y-a.Y))*(b.X-a.X), winding})
+		}
+	}
+
+	sort.Slice(crossings, func(i int, j int) bool {
+		return crossings[i].x < crossings[j].x
+	})
+
+	spans := make([][2]float64, 0, len(crossings)/2)
+	winding := 0
+	for _, crossing := range crossings {
+		previousWinding := winding
+		winding += crossing.winding
+
+		if previousWinding == 0 && winding != 0 {
+			spans = append(spans, [2]float64{crossing.x, crossing.x})
+		} else if previousWinding != 0 && winding == 0 {
+			spans[len(spans)-1][1] = crossing.x
+		}
+	}
+
+	return spans
+}
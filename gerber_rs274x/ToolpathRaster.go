@@ -0,0 +1,112 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+)
+
+// generateRaster engraves the whole flattened image one scanline at a time, for laser controllers (like GRBL) that
+// support dynamic power mode.  The laser stays enabled with M4 for the whole job, and each G1 move along a scanline
+// sets its own power, so it only fires over copper.  Scanlines run alternately left to right and right to left, and
+// both the line spacing and the horizontal resolution are set by the raster DPI.  The image is flattened in millimetres
+// (with the image parameters already applied), so the coordinates go straight to the caller's translate/scale function
+func generateRaster(camo *CamOutput, parsedFile []DataBlock) error {
+	image, err := InterpretGerberFile(parsedFile)
+	if err != nil {
+		return err
+	}
+
+	pitch := MM_PER_INCH / camo.rasterDPI
+
+	// Pixels are on when their center is inside the copper, so the outline only needs to be a fraction of a pixel accurate
+	shapes, err := image.Flatten(pitch / 4.0)
+	if err != nil {
+		return err
+	}
+	polygons := getPolygonsWithHolesContours(shapes)
+
+	xMin, xMax, yMin, yMax := math.MaxFloat64, -math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64
+	for _, polygon := range polygons {
+		for _, point := range polygon {
+			xMin, xMax = math.Min(xMin, point.X), math.Max(xMax, point.X)
+			yMin, yMax = math.Min(yMin, point.Y), math.Max(yMax, point.Y)
+		}
+	}
+
+	fmt.Fprintf(camo.wrt, "; My CAM (raster, %.0f DPI)\n", camo.rasterDPI)
+	fmt.Fprintf(camo.wrt, "G90G40G17G21\n")
+	fmt.Fprintf(camo.wrt, "F%d\n", camo.feedrate)
+	fmt.Fprintf(camo.wrt, "M04S0\n")
+
+	if xMin <= xMax {
+		// The pixel grid starts on the left edge of the copper
+		columnX := func(column int) float64 {
+			return xMin + (float64(column) * pitch)
+		}
+
+		reverse := false
+		rows := int(math.Ceil((yMax - yMin) / pitch))
+		for row := 0; row < rows; row++ {
+			y := yMin + ((float64(row) + 0.5) * pitch)
+			runs := rasterRuns(scanlineSpans(polygons, y), xMin, pitch)
+			if len(runs) == 0 {
+				continue
+			}
+
+			camo.emitRasterRow(runs, y, reverse, columnX)
+			reverse = !reverse
+		}
+	}
+
+	fmt.Fprintf(camo.wrt, "M05\n")
+
+	return nil
+}
+
+// rasterRuns turns the spans of copper along a scanline into runs of whole pixels, given as [first, last) columns.  A
+// pixel is on when its center is inside a span, and runs that touch are merged so the laser doesn't stutter between them
+func rasterRuns(spans [][2]float64, xMin float64, pitch float64) [][2]int {
+	runs := make([][2]int, 0, len(spans))
+	for _, span := range spans {
+		first := int(math.Ceil(((span[0] - xMin) / pitch) - 0.5))
+		last := int(math.Floor(((span[1]-xMin)/pitch)-0.5)) + 1
+		if last <= first {
+			continue
+		}
+
+		if len(runs) > 0 && runs[len(runs)-1][1] >= first {
+			runs[len(runs)-1][1] = last
+		} else {
+			runs = append(runs, [2]int{first, last})
+		}
+	}
+
+	return runs
+}
+
+// emitRasterRow writes one scanline: a rapid to the start of the first run, then G1 moves at zero power across the gaps
+// and full power across the runs
+func (camo *CamOutput) emitRasterRow(runs [][2]int, y float64, reverse bool, columnX func(int) float64) {
+	type rasterMove struct {
+		column int
+		power  int
+	}
+
+	moves := make([]rasterMove, 0, 2*len(runs))
+	if !reverse {
+		for _, run := range runs {
+			moves = append(moves, rasterMove{run[0], 0}, rasterMove{run[1], camo.power})
+		}
+	} else {
+		for i := len(runs) - 1; i >= 0; i-- {
+			moves = append(moves, rasterMove{runs[i][1], 0}, rasterMove{runs[i][0], camo.power})
+		}
+	}
+
+	x, outY := camo.translateScale(columnX(moves[0].column), y)
+	fmt.Fprintf(camo.wrt, "G00X%fY%f\n", x, outY)
+	for _, move := range moves[1:] {
+		x, outY = camo.translateScale(columnX(move.column), y)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fS%dF%d\n", x, outY, move.power, camo.feedrate)
+	}
+}
@@ -0,0 +1,16 @@
+package gerber_rs274x
+
+// Regions can't be exposed as they are drawn, because a later object with clear polarity may cut a hole in them, so the
+// vector toolpath collects their contours, along with the shapes of the clear objects, and fills them all once the whole
+// image has been drawn
+
+// fillRegions fills every region collected from the image, with the clear objects subtracted from the dark regions
+// before them
+func (camo *CamOutput) fillRegions() {
+	if len(camo.regionLayers) == 0 {
+		return
+	}
+
+	camo.fillPolygons(getPolygonsWithHolesContours(FlattenLayers(camo.regionLayers)))
+	camo.regionLayers = nil
+}
@@ -0,0 +1,316 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+)
+
+// In vector mode, CamOutput is an ImageBackend: it exposes each graphics object of an interpreted image in turn.  Objects
+// are in image coordinates (millimetres, with the image parameters applied), which is what translateScale is given
+
+// BeginImage writes the program header for an image
+func (camo *CamOutput) BeginImage(image *GerberImage) error {
+	xMin, xMax, yMin, yMax := image.GetBounds()
+
+	fmt.Fprintf(camo.wrt, "; My CAM\n")
+	fmt.Fprintf(camo.wrt, "G90G40G17G21\n")
+	fmt.Fprintf(camo.wrt, "F300\n")
+
+	fmt.Fprintf(camo.wrt, "; X Bounds: (%f %f) Y Bounds: (%f %f)\n", xMin, xMax, yMin, yMax)
+	fmt.Printf("X Bounds: (%f %f) Y Bounds: (%f %f)\n", xMin, xMax, yMin, yMax)
+
+	camo.regionLayers = nil
+
+	// A negative image is exposed everywhere except where its objects are, which can't be built up one object at a time,
+	// so the whole flattened image is filled here and the objects themselves are skipped
+	camo.negativeImage = image.GetImagePolarity() == CLEAR_POLARITY
+	if camo.negativeImage {
+		shapes, err := image.Flatten(camo.chordTolerance)
+		if err != nil {
+			return err
+		}
+		camo.fillPolygons(getPolygonsWithHolesContours(shapes))
+	}
+
+	return nil
+}
+
+// DrawObject exposes a single graphics object.  Regions are held back until the end of the image, since a later clear
+// object may cut a hole in them.  A toolpath can't take back what it has already exposed, so clear flashes and draws
+// are only cut out of the regions
+func (camo *CamOutput) DrawObject(graphicsObject *GraphicsObject) error {
+	if camo.negativeImage {
+		return nil
+	}
+
+	if graphicsObject.objectType == REGION_OBJECT {
+		if polygon := graphicsObject.getRegionPolygon(camo.chordTolerance); len(polygon) >= 3 {
+			camo.regionLayers = append(camo.regionLayers, PolygonLayer{[]Polygon{polygon}, graphicsObject.polarity})
+		}
+		return nil
+	}
+
+	if graphicsObject.polarity == CLEAR_POLARITY {
+		polygons, err := graphicsObject.getShapePolygons(camo.chordTolerance)
+		if err != nil {
+			return err
+		}
+		camo.regionLayers = append(camo.regionLayers, PolygonLayer{polygons, CLEAR_POLARITY})
+		return nil
+	}
+
+	var err error
+	switch graphicsObject.objectType {
+	case FLASH_OBJECT:
+		err = camo.flashObject(graphicsObject)
+
+	case DRAW_OBJECT, ARC_OBJECT:
+		err = camo.makeTrace(graphicsObject)
+	}
+	camo.x, camo.y = graphicsObject.end.X, graphicsObject.end.Y
+
+	return err
+}
+
+// EndImage fills the regions collected from the image
+func (camo *CamOutput) EndImage() error {
+	camo.fillRegions()
+
+	return nil
+}
+
+// flashObject exposes a flash.  Plain circles and rectangles have their own fill patterns, anything else is filled using
+// its exact shape
+func (camo *CamOutput) flashObject(graphicsObject *GraphicsObject) error {
+	apertureTransform := graphicsObject.apertureTransform
+
+	switch aperture := graphicsObject.aperture.(type) {
+	case *CircleAperture:
+		if aperture.Hole == nil {
+			camo.flashCircle(graphicsObject.start, apertureTransform.Scale()*aperture.diameter)
+			return nil
+		}
+
+	case *RectangleAperture:
+		// Rotating the image by anything other than a multiple of 90 degrees tilts the rectangle
+		if aperture.Hole == nil && apertureTransform.isAxisAligned() {
+			size := apertureTransform.ApplyLinear(Point{aperture.xSize, aperture.ySize})
+			camo.flashRectangle(graphicsObject.start, math.Abs(size.X), math.Abs(size.Y))
+			return nil
+		}
+
+	case nil:
+		return fmt.Errorf("Attempt to flash undefined aperture %d", graphicsObject.apertureNumber)
+	}
+
+	layers, err := graphicsObject.GetPolygons(camo.chordTolerance)
+	if err != nil {
+		return err
+	}
+
+	// Macro primitives with exposure off erase the primitives before them, so the layers have to be flattened first
+	var polygons []Polygon
+	if len(layers) == 1 && layers[0].Polarity == DARK_POLARITY {
+		polygons = layers[0].Polygons
+	} else {
+		polygons = getPolygonsWithHolesContours(FlattenLayers(layers))
+	}
+	camo.fillPolygons(polygons)
+
+	return nil
+}
+
+func (camo *CamOutput) flashRectangleTall(center Point, xsiz float64, ysiz float64) {
+	cx, cy := center.X, center.Y
+	tw := camo.toolWidth
+	tw2 := tw / 2.0
+
+	dx := xsiz / 2.0
+	dy := ysiz / 2.0
+	fmt.Fprintln(camo.wrt, "\t\t; Rectangle: cx = ", cx, ", cy = ", cy, ", xsiz = ", xsiz, ", ysiz = ", ysiz)
+	x := -dx
+	x0, y0 := camo.translateScale(cx+x, cy-dy)
+	fmt.Fprintf(camo.wrt, "G00X%fY%f\n", x0, y0)
+	fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
+	for ; x <= dx; x += tw {
+		x0, y0 = camo.translateScale(cx+x, cy+dy)
+		x1, y1 := camo.translateScale(cx+x+tw2, cy+dy)
+		x2, y2 := camo.translateScale(cx+x+tw2, cy-dy)
+		x3, y3 := camo.translateScale(cx+x+tw, cy-dy)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0, y0, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1, y1, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x2, y2, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x3, y3, camo.feedrate)
+	}
+	fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0, y0, camo.feedrate)
+	fmt.Fprintf(camo.wrt, "M05\n")
+}
+
+func (camo *CamOutput) flashRectangleWide(center Point, xsiz float64, ysiz float64) {
+	cx, cy := center.X, center.Y
+	tw := camo.toolWidth
+
+	dx := xsiz / 2.0
+	dy := ysiz / 2.0
+	fmt.Fprintln(camo.wrt, "\t\t; Rectangle: cx = ", cx, ", cy = ", cy, ", xsiz = ", xsiz, ", ysiz = ", ysiz)
+
+	y := -dy
+	x0, y0 := camo.translateScale(cx-dx, cy+y)
+	fmt.Fprintf(camo.wrt, "G00X%fY%f\n", x0, y0)
+	fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
+
+	tw2 := tw / 2.0
+	for ; y <= dy; y += tw {
+		x0, y0 = camo.translateScale(cx+dx, cy+y)
+		x1, y1 := camo.translateScale(cx+dx, cy+y+tw2)
+		x2, y2 := camo.translateScale(cx-dx, cy+y+tw2)
+		x3, y3 := camo.translateScale(cx-dx, cy+y+tw)
+
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0, y0, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1, y1, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x2, y2, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x3, y3, camo.feedrate)
+	}
+	fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0, y0, camo.feedrate)
+	fmt.Fprintf(camo.wrt, "M05\n")
+}
+
+func (camo *CamOutput) flashRectangle(center Point, xsiz float64, ysiz float64) {
+	if xsiz < ysiz {
+		camo.flashRectangleTall(center, xsiz, ysiz)
+	} else {
+		camo.flashRectangleWide(center, xsiz, ysiz)
+	}
+}
+
+func (camo *CamOutput) flashCircle(center Point, dia float64) {
+	cx, cy := center.X, center.Y
+	rad := dia / 2.0
+
+	x0, y0 := camo.translateScale(cx-rad, cy)
+	fmt.Fprintf(camo.wrt, "G00X%fY%f\n", x0, y0)
+	fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
+
+	var tw = camo.toolWidth
+	tw2 := tw / 2.0
+
+	for dx := -rad + tw2; dx < rad; dx += tw2 {
+		dy := math.Sqrt(math.Pow(rad, 2.0) - math.Pow(dx, 2.0))
+		x1, y1 := camo.translateScale(cx+dx, cy+dy)
+		x2, y2 := camo.translateScale(cx+dx, cy-dy)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1, y1, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x2, y2, camo.feedrate)
+	}
+	x3, y3 := camo.translateScale(cx+rad, cy)
+	fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x3, y3, camo.feedrate)
+	fmt.Fprintf(camo.wrt, "M05\n")
+}
+
+// makeTrace exposes a draw or arc.  Circle apertures round off both ends and run passes one tool width apart along the
+// trace, while rectangles sweep their outline from one end to the other, which is filled
+func (camo *CamOutput) makeTrace(graphicsObject *GraphicsObject) error {
+	aperture, isCircle := graphicsObject.aperture.(*CircleAperture)
+	if !isCircle || aperture.Hole != nil {
+		// Converting to polygons rejects the apertures that can't be used for draws at all
+		layers, err := graphicsObject.GetPolygons(camo.chordTolerance)
+		if err != nil {
+			return err
+		}
+
+		// Only circles can stroke arcs
+		if graphicsObject.objectType == ARC_OBJECT {
+			return fmt.Errorf("Aperture %d can't be used for circular draws, only circle apertures can", graphicsObject.apertureNumber)
+		}
+
+		camo.fillPolygons(layers[0].Polygons)
+		return nil
+	}
+
+	dia := graphicsObject.apertureTransform.Scale() * aperture.diameter
+
+	// A single quadrant arc that ends where it starts has no length, so it's drawn like a zero length line
+	if graphicsObject.objectType == ARC_OBJECT && graphicsObject.sweepAngle != 0.0 {
+		camo.makeArcTrace(graphicsObject.start, graphicsObject.end, graphicsObject.center, graphicsObject.sweepAngle, dia)
+		return nil
+	}
+
+	camo.flashCircle(graphicsObject.end, dia)
+	camo.flashCircle(graphicsObject.start, dia)
+
+	x0, y0 := camo.translateScale(graphicsObject.start.X, graphicsObject.start.Y)
+	x1, y1 := camo.translateScale(graphicsObject.end.X, graphicsObject.end.Y)
+
+	r := dia / 2
+	dx := x1 - x0
+	dy := y1 - y0
+	dist := math.Sqrt(dx*dx + dy*dy)
+	if dist < camo.toolWidth {
+		fmt.Println("Trace too short")
+		return nil
+	}
+	sin := dx / dist
+	cos := dy / dist
+	fmt.Fprintln(camo.wrt, "; makeTrace ",
+		", x0 = ", x0, ", y0 = ", y0,
+		", x1 = ", x1, ", y1 = ", y1,
+		", dx = ", dx, ", dy = ", dy,
+		", dist = ", dist, ", wid = ", dia,
+		", dia = ", dia, ", r = ", r,
+		", sin = ", sin, ", cos = ", cos)
+
+	fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
+	for i0 := -r; i0 < r; i0 += camo.toolWidth {
+		i1 := i0 + camo.toolWidth/2.0
+
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0-i0*cos, y0+i0*sin, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1-i0*cos, y1+i0*sin, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x1-i1*cos, y1+i1*sin, camo.feedrate)
+		fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x0-i1*cos, y0+i1*sin, camo.feedrate)
+	}
+	fmt.Fprintf(camo.wrt, "M05\n")
+
+	return nil
+}
+
+// makeArcTrace strokes an arc with a circle aperture of the given diameter, by rounding off both ends and then running
+// concentric passes one tool width apart along the arc.  Each pass is a polyline that never strays more than the chord
+// tolerance from the true arc.  Apertures wider than the arc's radius sweep over its center, so the swept area is
+// filled instead
+func (camo *CamOutput) makeArcTrace(start Point, end Point, center Point, sweepAngle float64, dia float64) {
+	r := dia / 2.0
+	arcRadius := math.Hypot(start.X-center.X, start.Y-center.Y)
+	startAngle := math.Atan2(start.Y-center.Y, start.X-center.X)
+
+	if r >= arcRadius {
+		camo.fillPolygons(strokeArcPolygons(nil, r, true, start, center, sweepAngle, camo.chordTolerance))
+		return
+	}
+
+	camo.flashCircle(end, dia)
+	camo.flashCircle(start, dia)
+
+	tw := camo.toolWidth
+	passes := int(math.Max(1.0, math.Floor(dia/tw)))
+	firstOffset := -float64(passes-1) * tw / 2.0
+
+	// Alternate the direction of the passes, so each one starts where the last one finished
+	reverse := false
+	for pass := 0; pass < passes; pass++ {
+		radius := arcRadius + firstOffset + (float64(pass) * tw)
+		points := arcPoints(center, radius, startAngle, sweepAngle, camo.chordTolerance)
+		if reverse {
+			points = Polygon(points).Reverse()
+		}
+
+		xs, ys := camo.translateScale(points[0].X, points[0].Y)
+		fmt.Fprintf(camo.wrt, "G00X%fY%f\n", xs, ys)
+		fmt.Fprintf(camo.wrt, "M03S%d\n", camo.power)
+		for _, point := range points[1:] {
+			x, y := camo.translateScale(point.X, point.Y)
+			fmt.Fprintf(camo.wrt, "G01X%fY%fF%d\n", x, y, camo.feedrate)
+		}
+		fmt.Fprintf(camo.wrt, "M05\n")
+
+		reverse = !reverse
+	}
+}
@@ -0,0 +1,100 @@
+package gerber_rs274x
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+type testWriteCloser struct {
+	bytes.Buffer
+}
+
+func (wrt *testWriteCloser) Close() error {
+	return nil
+}
+
+// toolpathSpan is one pass of the laser, from a rapid move to the start of the pass to the cut that follows it
+type toolpathSpan struct {
+	startX float64
+	endX   float64
+	y      float64
+}
+
+func generateTestToolpath(t *testing.T, text string, toolWidth float64) []toolpathSpan {
+	t.Helper()
+
+	parsedFile, _, err := ParseGerberFile(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ParseGerberFile failed: %v", err)
+	}
+
+	wrt := &testWriteCloser{}
+	camo := NewCamOutput(wrt, 300, toolWidth, 0.0, 0.0, 1000, func(x float64, y float64) (float64, float64) {
+		return x, y
+	})
+	if err := GenerateToolpath(camo, parsedFile); err != nil {
+		t.Fatalf("GenerateToolpath failed: %v", err)
+	}
+
+	var spans []toolpathSpan
+	var startX, startY float64
+	for _, line := range strings.Split(wrt.String(), "\n") {
+		var endX, endY float64
+		var feedrate int
+		if _, err := fmt.Sscanf(line, "G00X%fY%f", &startX, &startY); err == nil {
+			continue
+		}
+		if _, err := fmt.Sscanf(line, "G01X%fY%fF%d", &endX, &endY, &feedrate); err == nil {
+			spans = append(spans, toolpathSpan{startX, endX, endY})
+			startX, startY = endX, endY
+		}
+	}
+	return spans
+}
+
+func TestClearFlashCutsRegion(t *testing.T) {
+	// A 10mm square pour with a 4mm clear flash in the middle of it
+	spans := generateTestToolpath(t, `%FSLAX26Y26*%
+%MOMM*%
+%ADD10C,4*%
+G01*
+G36*
+X0Y0D02*
+X10000000Y0D01*
+X10000000Y10000000D01*
+X0Y10000000D01*
+X0Y0D01*
+G37*
+%LPC*%
+D10*
+X5000000Y5000000D03*
+M02*
+`, 0.5)
+
+	if len(spans) == 0 {
+		t.Fatal("Got no passes")
+	}
+
+	holeRows := 0
+	for _, span := range spans {
+		offset := span.y - 5.0
+		if math.Abs(offset) >= 2.0 {
+			continue
+		}
+
+		// The hole is approximated by chords, which cut off a little less than the true circle
+		halfChord := math.Sqrt(4.0-(offset*offset)) - 0.05
+		xMin, xMax := math.Min(span.startX, span.endX), math.Max(span.startX, span.endX)
+		if xMin < 5.0+halfChord && xMax > 5.0-halfChord {
+			t.Errorf("Pass from %f to %f at y = %f runs through the hole", span.startX, span.endX, span.y)
+		}
+		holeRows++
+	}
+
+	if holeRows == 0 {
+		t.Error("No passes run beside the hole")
+	}
+}
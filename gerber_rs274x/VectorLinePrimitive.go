@@ -2,8 +2,7 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	"github.com/ungerik/go-cairo"
-	_ "math"
+	"math"
 )
 
 type VectorLinePrimitive struct {
@@ -46,9 +45,25 @@ func (primitive *VectorLinePrimitive) GetPrimitiveBounds(env *ExpressionEnvironm
 	return 0.0, 0.0, 0.0, 0.0
 }
 
-func (primitive *VectorLinePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
-	return nil
+func (primitive *VectorLinePrimitive) getPrimitivePolygons(env *ExpressionEnvironment, tolerance float64) (PolygonLayer, error) {
+	halfWidth := primitive.lineWidth.EvaluateExpression(env) / 2.0
+	startX := primitive.startX.EvaluateExpression(env)
+	startY := primitive.startY.EvaluateExpression(env)
+	endX := primitive.endX.EvaluateExpression(env)
+	endY := primitive.endY.EvaluateExpression(env)
+
+	// The line has square ends that stop at its end points, so it's a rectangle along the line
+	lineAngle := math.Atan2(endY-startY, endX-startX)
+	offsetX := -halfWidth * math.Sin(lineAngle)
+	offsetY := halfWidth * math.Cos(lineAngle)
+	polygon := Polygon{
+		{startX - offsetX, startY - offsetY},
+		{endX - offsetX, endY - offsetY},
+		{endX + offsetX, endY + offsetY},
+		{startX + offsetX, startY + offsetY},
+	}
+
+	return newPrimitiveLayer([]Polygon{polygon}, primitive.exposure.EvaluateExpression(env), primitive.rotationAngle.EvaluateExpression(env)), nil
 }
 
 func (primitive *VectorLinePrimitive) String() string {
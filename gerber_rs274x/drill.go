@@ -7,92 +7,595 @@ import (
 	"math"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 type Tool struct {
 	typ  string
 	size float64
+
+	// Optional tool parameters, zero when the file doesn't give them.  The feed and retract rates are in millimetres
+	// per minute and the depth offset in millimetres.  The spindle speed is kept as written, since CAM programs
+	// disagree about whether it's in RPM or thousands of RPM
+	feed        float64
+	speed       float64
+	retractRate float64
+	depth       float64
+
+	// The bit from the tool library the tool was mapped to, if any
+	bit *DrillBit
 }
 
 // Step types:
 //  T change tool
 //  D Drill hole
+//  S Drill a slot (G85) from x, y to x2, y2
+//  G Rapid move to x, y with the tool up (route mode)
+//  P Plunge the tool (M15)
+//  U Retract the tool (M16 or M17)
+//  L Route in a straight line to x, y
+//  C Route clockwise around cx, cy to x, y
+//  A Route counterclockwise around cx, cy to x, y
 type Step struct {
-	typ   string
-	tooln int
-	x, y  float64
+	typ    string
+	tooln  int
+	x, y   float64
+	x2, y2 float64
+	cx, cy float64
 }
 
+// DrlData holds a parsed Excellon drill file.  All coordinates and sizes are converted to millimetres as they're
+// parsed, whatever the units of the file
 type DrlData struct {
 	units string
 	Tools []*Tool
 	Steps []*Step
 }
 
-var defToolRe *regexp.Regexp
-var changeToolRe *regexp.Regexp
-var drillHoleRe *regexp.Regexp
+// drlParser keeps track of the modal state of an Excellon file while it's being parsed
+type drlParser struct {
+	drl *DrlData
+
+	inHeader bool
+	finished bool
+
+	// Integer coordinates have an implied decimal point, placed according to the number of integer and decimal
+	// digits.  With leadingZeros (LZ) the trailing zeros may be left off, otherwise (TZ) the leading zeros may be
+	scale        float64
+	intDigits    int
+	decDigits    int
+	formatSet    bool
+	leadingZeros bool
+
+	incremental bool
+	routeMode   bool
+	routeCode   int
+	tooln       int
+	x, y        float64
+	hitMade     bool
+}
+
+var drlWordRe *regexp.Regexp
+var drlFormatRe *regexp.Regexp
+var drlFileFormatRe *regexp.Regexp
 
 func init() {
-	defToolRe = regexp.MustCompilePOSIX(`^T([0-9]+)([A-Z])([0-9.-]+)`)
-	changeToolRe = regexp.MustCompilePOSIX(`^T([0-9]+)$`)
-	drillHoleRe = regexp.MustCompilePOSIX(`^X([0-9.-]+)Y([0-9.-]+)`)
+	drlWordRe = regexp.MustCompilePOSIX(`^([A-Z])([+-]?[0-9.]*)`)
+	drlFormatRe = regexp.MustCompilePOSIX(`^(0+)\.(0+)$`)
+	drlFileFormatRe = regexp.MustCompilePOSIX(`FILE_FORMAT=([0-9]):([0-9])`)
 }
 
-func parseDrillHole(ln string) (step *Step, err error) {
-	pdh := drillHoleRe.FindAllStringSubmatch(ln, -1)
-	if len(pdh) == 0 {
-		return nil, fmt.Errorf("bad directive")
+// Header settings that don't change the geometry of the file
+var ignoredDrlSettings = map[string]bool{
+	"AFS":    true,
+	"ATC":    true,
+	"BLKD":   true,
+	"DETECT": true,
+	"OSTOP":  true,
+	"SBK":    true,
+	"SG":     true,
+	"TCST":   true,
+	"VER":    true,
+}
+
+type drlWord struct {
+	letter byte
+	value  string
+}
+
+// splitDrlWords splits a line such as T1C0.8F200 or X1.5Y2G85X3Y2 into its letter and value pairs
+func splitDrlWords(ln string) (words []drlWord, err error) {
+	for ln != "" {
+		pw := drlWordRe.FindStringSubmatch(ln)
+		if pw == nil {
+			return nil, fmt.Errorf("Unable to parse %s", ln)
+		}
+		words = append(words, drlWord{pw[1][0], pw[2]})
+		ln = ln[len(pw[0]):]
 	}
-	x, err := strconv.ParseFloat(pdh[0][1], 64)
-	if err != nil {
-		return //
+	return //
+}
+
+func newDrlParser(drl *DrlData) *drlParser {
+	parser := &drlParser{drl: drl}
+
+	// Excellon files are in inches, with leading zeros suppressed, unless they say otherwise
+	parser.setUnits("INCH")
+	return parser
+}
+
+func (parser *drlParser) setUnits(units string) {
+	parser.drl.units = units
+	if units == "INCH" {
+		parser.scale = 25.4
+		if !parser.formatSet {
+			parser.intDigits, parser.decDigits = 2, 4
+		}
+	} else {
+		parser.scale = 1.0
+		if !parser.formatSet {
+			parser.intDigits, parser.decDigits = 3, 3
+		}
+	}
+}
+
+// parseUnits parses the units line of the header, such as METRIC, INCH,LZ or METRIC,TZ,000.000
+func (parser *drlParser) parseUnits(ln string) error {
+	fields := strings.Split(ln, ",")
+	if fields[0] != "INCH" && fields[0] != "METRIC" {
+		return fmt.Errorf("Unknown units %s", fields[0])
 	}
-	y, err := strconv.ParseFloat(pdh[0][2], 64)
+
+	for _, field := range fields[1:] {
+		switch {
+		case field == "LZ":
+			parser.leadingZeros = true
+		case field == "TZ":
+			parser.leadingZeros = false
+		case drlFormatRe.MatchString(field):
+			pf := drlFormatRe.FindStringSubmatch(field)
+			parser.intDigits, parser.decDigits = len(pf[1]), len(pf[2])
+			parser.formatSet = true
+		default:
+			return fmt.Errorf("Unknown units setting %s", field)
+		}
+	}
+
+	parser.setUnits(fields[0])
+	return nil
+}
+
+// parseComment picks up the coordinate format some CAM programs only give in a comment, such as ;FILE_FORMAT=2:4
+func (parser *drlParser) parseComment(comment string) {
+	pff := drlFileFormatRe.FindStringSubmatch(comment)
+	if pff == nil {
+		return
+	}
+	parser.intDigits, _ = strconv.Atoi(pff[1])
+	parser.decDigits, _ = strconv.Atoi(pff[2])
+	parser.formatSet = true
+}
+
+// parseNumber converts a coordinate to millimetres.  Numbers with a decimal point are taken as they are, and ones
+// without use the file's integer format
+func (parser *drlParser) parseNumber(value string) (number float64, err error) {
+	if strings.Contains(value, ".") {
+		number, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0.0, fmt.Errorf("Unable to parse number %s", value)
+		}
+		return number * parser.scale, nil
+	}
+
+	digits := strings.TrimLeft(value, "+-")
+	if digits == "" {
+		return 0.0, fmt.Errorf("Missing number")
+	}
+	if parser.leadingZeros {
+		// The trailing zeros were left off, so they have to be put back before the decimal point can be placed
+		for len(digits) < parser.intDigits+parser.decDigits {
+			digits += "0"
+		}
+	}
+	number, err = strconv.ParseFloat(digits, 64)
 	if err != nil {
-		return //
+		return 0.0, fmt.Errorf("Unable to parse number %s", value)
+	}
+	number /= math.Pow(10.0, float64(parser.decDigits))
+	if strings.HasPrefix(value, "-") {
+		number = -number
+	}
+	return number * parser.scale, nil
+}
+
+// getPosition works out where the X and Y words of a coordinate take the tool.  Missing axes keep their current value
+func (parser *drlParser) getPosition(coords map[byte]string) (x float64, y float64, err error) {
+	x, y = parser.x, parser.y
+	if value, found := coords['X']; found {
+		var number float64
+		if number, err = parser.parseNumber(value); err != nil {
+			return //
+		}
+		if parser.incremental {
+			x += number
+		} else {
+			x = number
+		}
 	}
-	step = &Step{
-		typ: "D",
-		x:   x,
-		y:   y,
+	if value, found := coords['Y']; found {
+		var number float64
+		if number, err = parser.parseNumber(value); err != nil {
+			return //
+		}
+		if parser.incremental {
+			y += number
+		} else {
+			y = number
+		}
 	}
 	return //
 }
 
-func parseChangeTool(ln string) (step *Step, err error) {
-	pct := changeToolRe.FindAllStringSubmatch(ln, -1)
-	if len(pct) == 0 {
-		return nil, fmt.Errorf("bad directive")
+func (parser *drlParser) addStep(step *Step) {
+	step.tooln = parser.tooln
+	parser.drl.Steps = append(parser.drl.Steps, step)
+}
+
+func (parser *drlParser) selectTool(tooln int) error {
+	if tooln != 0 && parser.drl.getTool(tooln) == nil {
+		return fmt.Errorf("Tool %d selected without being defined", tooln)
 	}
-	tooln, err := strconv.Atoi(pct[0][1])
+	parser.tooln = tooln
+	parser.hitMade = false
+	parser.addStep(&Step{typ: "T"})
+	return nil
+}
+
+// parseTool handles tool definitions (T1C0.8, with optional F, S, B, Z and H parameters) and tool selections (T1).
+// Defining a tool in the body of the file selects it as well
+func (parser *drlParser) parseTool(words []drlWord) error {
+	tooln, err := strconv.Atoi(words[0].value)
+	if err != nil || tooln < 0 {
+		return fmt.Errorf("Unable to parse tool number T%s", words[0].value)
+	}
+	if len(words) == 1 {
+		return parser.selectTool(tooln)
+	}
+
+	tool := parser.drl.getTool(tooln)
+	if tool == nil {
+		tool = &Tool{}
+	}
+	for _, word := range words[1:] {
+		value, err := strconv.ParseFloat(word.value, 64)
+		if err != nil {
+			return fmt.Errorf("Unable to parse tool parameter %c%s", word.letter, word.value)
+		}
+		switch word.letter {
+		case 'C':
+			tool.typ = "C"
+			tool.size = value * parser.scale
+		case 'F':
+			tool.feed = value * parser.scale
+		case 'S':
+			tool.speed = value
+		case 'B':
+			tool.retractRate = value * parser.scale
+		case 'Z':
+			tool.depth = value * parser.scale
+		case 'H':
+			// The maximum hit count is only of interest to the drilling machine
+		default:
+			return fmt.Errorf("Unknown tool parameter %c%s", word.letter, word.value)
+		}
+	}
+	if tool.typ == "" {
+		return fmt.Errorf("Tool %d defined without a diameter", tooln)
+	}
+
+	for i := len(parser.drl.Tools); i <= tooln; i++ {
+		parser.drl.Tools = append(parser.drl.Tools, nil)
+	}
+	parser.drl.Tools[tooln] = tool
+
+	if parser.inHeader {
+		return nil
+	}
+	return parser.selectTool(tooln)
+}
+
+// parseCoordinates handles everything built from G codes and coordinates: hits, G85 slots, R repeats, route moves
+// and the G05, G90 and G91 mode changes
+func (parser *drlParser) parseCoordinates(words []drlWord) error {
+	gCode := -1
+	repeat := 0
+	slot := false
+	var slotX, slotY float64
+	coords := make(map[byte]string, 5)
+
+	for _, word := range words {
+		switch word.letter {
+		case 'G':
+			code, err := strconv.Atoi(word.value)
+			if err != nil {
+				return fmt.Errorf("Unable to parse G code G%s", word.value)
+			}
+			if code != 85 {
+				if gCode != -1 {
+					return fmt.Errorf("More than one G code on a line")
+				}
+				gCode = code
+				continue
+			}
+
+			// G85 sits between the start and the end of a slot
+			if slot {
+				return fmt.Errorf("More than one G85 on a line")
+			}
+			if slotX, slotY, err = parser.getPosition(coords); err != nil {
+				return err
+			}
+			parser.x, parser.y = slotX, slotY
+			slot = true
+			coords = make(map[byte]string, 5)
+		case 'X', 'Y', 'I', 'J', 'A':
+			if _, found := coords[word.letter]; found {
+				return fmt.Errorf("Repeated %c coordinate", word.letter)
+			}
+			coords[word.letter] = word.value
+		case 'R':
+			var err error
+			if repeat, err = strconv.Atoi(word.value); err != nil || repeat < 1 {
+				return fmt.Errorf("Unable to parse repeat count R%s", word.value)
+			}
+		default:
+			return fmt.Errorf("Unexpected %c%s", word.letter, word.value)
+		}
+	}
+
+	switch gCode {
+	case -1:
+	case 0, 1, 2, 3:
+		parser.routeMode = true
+		parser.routeCode = gCode
+	case 5:
+		parser.routeMode = false
+	case 40:
+		// Cutter compensation off is the only compensation mode supported
+	case 90:
+		parser.incremental = false
+	case 91:
+		parser.incremental = true
+	default:
+		return fmt.Errorf("Unsupported G code G%02d", gCode)
+	}
+
+	switch {
+	case slot:
+		return parser.addSlot(slotX, slotY, coords)
+	case repeat > 0:
+		return parser.addRepeats(repeat, coords)
+	case len(coords) == 0:
+		return nil
+	case parser.routeMode:
+		return parser.addRoute(coords)
+	default:
+		return parser.addHit(coords)
+	}
+}
+
+func (parser *drlParser) checkTool() error {
+	if parser.tooln == 0 {
+		return fmt.Errorf("Drilling without a tool selected")
+	}
+	return nil
+}
+
+func (parser *drlParser) addHit(coords map[byte]string) error {
+	if err := parser.checkTool(); err != nil {
+		return err
+	}
+	for letter := range coords {
+		if letter != 'X' && letter != 'Y' {
+			return fmt.Errorf("Hits only take X and Y coordinates")
+		}
+	}
+
+	x, y, err := parser.getPosition(coords)
 	if err != nil {
-		return //
+		return err
+	}
+	parser.x, parser.y = x, y
+	parser.hitMade = true
+	parser.addStep(&Step{typ: "D", x: x, y: y})
+	return nil
+}
+
+func (parser *drlParser) addSlot(startX float64, startY float64, coords map[byte]string) error {
+	if err := parser.checkTool(); err != nil {
+		return err
 	}
-	step = &Step{
-		typ:   "T",
-		tooln: tooln,
+
+	x, y, err := parser.getPosition(coords)
+	if err != nil {
+		return err
 	}
-	return //
+	parser.x, parser.y = x, y
+	parser.addStep(&Step{typ: "S", x: startX, y: startY, x2: x, y2: y})
+	return nil
+}
+
+// addRepeats repeats the last hit, moving by the X and Y spacing each time
+func (parser *drlParser) addRepeats(repeat int, coords map[byte]string) error {
+	if !parser.hitMade {
+		return fmt.Errorf("Repeat without a hit to repeat")
+	}
+
+	var dx, dy float64
+	var err error
+	if value, found := coords['X']; found {
+		if dx, err = parser.parseNumber(value); err != nil {
+			return err
+		}
+	}
+	if value, found := coords['Y']; found {
+		if dy, err = parser.parseNumber(value); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < repeat; i++ {
+		parser.x += dx
+		parser.y += dy
+		parser.addStep(&Step{typ: "D", x: parser.x, y: parser.y})
+	}
+	return nil
 }
 
-func parseDefineTool(ln string) (tool *Tool, tooln int, err error) {
-	pdt := defToolRe.FindAllStringSubmatch(ln, -1)
-	if len(pdt) == 0 {
-		return nil, -1, fmt.Errorf("bad directive")
+func (parser *drlParser) addRoute(coords map[byte]string) error {
+	if err := parser.checkTool(); err != nil {
+		return err
 	}
-	tooln, err = strconv.Atoi(pdt[0][1])
+
+	startX, startY := parser.x, parser.y
+	x, y, err := parser.getPosition(coords)
 	if err != nil {
-		return //
+		return err
 	}
-	tool = &Tool{
-		typ: pdt[0][2],
+	parser.x, parser.y = x, y
+
+	switch parser.routeCode {
+	case 0:
+		parser.addStep(&Step{typ: "G", x: x, y: y})
+		return nil
+	case 1:
+		parser.addStep(&Step{typ: "L", x: x, y: y})
+		return nil
 	}
-	tool.size, err = strconv.ParseFloat(pdt[0][3], 64)
-	return //
+
+	step := &Step{typ: "A", x: x, y: y}
+	if parser.routeCode == 2 {
+		step.typ = "C"
+	}
+
+	if value, found := coords['A']; found {
+		// With a radius, the arc is the shorter of the two that fit between the end points
+		radius, err := parser.parseNumber(value)
+		if err != nil {
+			return err
+		}
+		dx, dy := x-startX, y-startY
+		chord := math.Hypot(dx, dy)
+		if chord == 0.0 || radius < chord/2.0 {
+			return fmt.Errorf("Arc radius %f is too small to reach from (%f, %f) to (%f, %f)", radius, startX, startY, x, y)
+		}
+		offset := math.Sqrt((radius*radius)-(chord*chord/4.0)) / chord
+		if step.typ == "C" {
+			offset = -offset
+		}
+		step.cx = ((startX + x) / 2.0) - (dy * offset)
+		step.cy = ((startY + y) / 2.0) + (dx * offset)
+	} else {
+		// The centre is given relative to the start of the arc
+		i, iFound := coords['I']
+		j, jFound := coords['J']
+		if !iFound && !jFound {
+			return fmt.Errorf("Arc without a radius or centre")
+		}
+		step.cx, step.cy = startX, startY
+		if iFound {
+			var offset float64
+			if offset, err = parser.parseNumber(i); err != nil {
+				return err
+			}
+			step.cx += offset
+		}
+		if jFound {
+			var offset float64
+			if offset, err = parser.parseNumber(j); err != nil {
+				return err
+			}
+			step.cy += offset
+		}
+	}
+
+	parser.addStep(step)
+	return nil
 }
 
-//			tool, tooln := parseTool(ln)
+// parseLine parses one line of the file, with any comment already removed
+func (parser *drlParser) parseLine(ln string) error {
+	if parser.finished || ln == "" {
+		return nil
+	}
+
+	switch ln {
+	case "M48":
+		parser.inHeader = true
+		return nil
+	case "%", "M95":
+		parser.inHeader = false
+		return nil
+	case "M30", "M00":
+		parser.finished = true
+		return nil
+	case "M71":
+		parser.setUnits("METRIC")
+		return nil
+	case "M72":
+		parser.setUnits("INCH")
+		return nil
+	case "M15":
+		if err := parser.checkTool(); err != nil {
+			return err
+		}
+		parser.addStep(&Step{typ: "P", x: parser.x, y: parser.y})
+		return nil
+	case "M16", "M17":
+		parser.addStep(&Step{typ: "U", x: parser.x, y: parser.y})
+		return nil
+	case "FMAT,1", "FMAT,2":
+		return nil
+	case "ICI,ON":
+		parser.incremental = true
+		return nil
+	case "ICI,OFF":
+		parser.incremental = false
+		return nil
+	}
+
+	if strings.HasPrefix(ln, "INCH") || strings.HasPrefix(ln, "METRIC") {
+		return parser.parseUnits(ln)
+	}
+	if strings.HasPrefix(ln, "M47,") {
+		// Operator messages
+		return nil
+	}
+	if setting := strings.Split(ln, ",")[0]; ignoredDrlSettings[setting] {
+		return nil
+	}
+
+	words, err := splitDrlWords(ln)
+	if err != nil {
+		return err
+	}
+	switch words[0].letter {
+	case 'T':
+		return parser.parseTool(words)
+	case 'G', 'X', 'Y', 'R':
+		return parser.parseCoordinates(words)
+	default:
+		return fmt.Errorf("Unknown command %s", ln)
+	}
+}
+
+// getTool returns a defined tool, or nil if there's no such tool
+func (drl *DrlData) getTool(tooln int) *Tool {
+	if tooln < 0 || tooln >= len(drl.Tools) {
+		return nil
+	}
+	return drl.Tools[tooln]
+}
 
 func NewDrlData() *DrlData {
 	return &DrlData{}
@@ -107,6 +610,32 @@ type DrlCAM struct {
 	DrillF         int
 	Tooln          int
 	TranslateScale func(float64, float64) (float64, float64)
+
+	// Slots and routed holes.  EndMill says whether the tools are end mills, unless they've been mapped to library bits,
+	// which know for themselves.  End mills can cut sideways, so they mill along the slot in passes SlotPassDepth deep,
+	// at SlotF, either plunging at the start of each pass or ramping down along the slot with SlotRamp.  Drill bits
+	// can't, so they drill a row of hits instead, each overlapping the one before by SlotOverlap of the diameter
+	EndMill       bool
+	SlotPassDepth float64
+	SlotRamp      bool
+	SlotF         int
+	SlotOverlap   float64
+
+	// Drilling.  Each tool's spindle speed and plunge feed come from ToolTable, then from the library bit the tool was
+	// mapped to, then from the drill file's tool parameters (with S multiplied by ToolSpeedScale, for files that give it
+	// in thousands of RPM), then from SpindleSpeed and DrillF.  With PeckDepth set, holes are drilled in pecks that
+	// deep, pulling out to SafeZ in between to clear the chips.  CannedCycles drills with G81, or G83 when pecking,
+	// instead of spelling out the moves.  KeepSpindleOn leaves the spindle running from one hit to the next until the
+	// tool is changed.  A canned cycle runs every hit with the spindle on, so CannedCycles needs KeepSpindleOn too
+	ToolTable      map[int]DrlToolSettings
+	ToolSpeedScale float64
+	SpindleSpeed   int
+	PeckDepth      float64
+	CannedCycles   bool
+	KeepSpindleOn  bool
+
+	spindleOn bool
+	cycleOn   bool
 }
 
 type DrillBounds struct {
@@ -126,26 +655,63 @@ func (db *DrillBounds) Get() (xMin, xMax, yMin, yMax float64) {
 
 func (drl *DrlData) genDrillHole(cam *DrlCAM, st *Step) {
 	w := cam.Wrt
-	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
 	x, y := cam.TranslateScale(st.x, st.y)
+	speed, feed := drl.getToolSpeeds(cam)
+
+	if cam.CannedCycles {
+		drl.genCannedHole(cam, x, y, speed, feed)
+		return
+	}
+
+	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
 	fmt.Fprintf(w, "G00X%fY%f\n", x, y)
-	fmt.Fprintf(w, "M3S10000\n")
-	fmt.Fprintf(w, "G01Z%fF%d\n", cam.DrillZ, cam.DrillF)
+	drl.startSpindle(cam, speed)
+
+	depths := getPassDepths(cam.DrillZ, cam.PeckDepth)
+	for i, z := range depths {
+		if i > 0 {
+			// Pull out to clear the chips, then drop back quickly to just above the bottom of the hole
+			fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
+			fmt.Fprintf(w, "G00Z%f\n", depths[i-1]+DRILL_PECK_CLEARANCE)
+		}
+		fmt.Fprintf(w, "G01Z%fF%d\n", z, feed)
+	}
+
 	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
-	fmt.Fprintf(w, "M5\n")
+	if !cam.KeepSpindleOn {
+		drl.stopSpindle(cam)
+	}
 }
 
 func (drl *DrlData) genChangeTool(cam *DrlCAM, st *Step) {
 	w := cam.Wrt
+	drl.stopSpindle(cam)
 	fmt.Fprintf(w, "G00Z%f\n", cam.ChangeZ)
 	fmt.Fprintln(w, "G00X0Y0") // go home
 	fmt.Fprintln(w, "M0")      // pause
 	fmt.Fprintf(w, "G00Z%f\n", cam.SafeZ)
 }
 
-func (drl *DrlData) GenGcode(cam *DrlCAM) {
+func (drl *DrlData) GenGcode(cam *DrlCAM) error {
+	if cam.SlotOverlap < 0.0 || cam.SlotOverlap >= 1.0 {
+		return fmt.Errorf("Slot hit overlap must be at least 0 and less than 1, got %f", cam.SlotOverlap)
+	}
+	if cam.PeckDepth < 0.0 {
+		return fmt.Errorf("Peck depth can't be negative, got %f", cam.PeckDepth)
+	}
+	if cam.CannedCycles && !cam.KeepSpindleOn {
+		return fmt.Errorf("Canned cycles keep the spindle on between hits, so they need KeepSpindleOn")
+	}
+	cam.spindleOn, cam.cycleOn = false, false
+
 	fmt.Fprintf(cam.Wrt, "; My DrlCAM\n")
 	fmt.Fprintf(cam.Wrt, "G90G40G17G21\n")
+
+	// Routed sections run from a plunge (M15) to a retract (M16 or M17), and are cut the same way as slots
+	var position Point
+	var routeStart Point
+	var route []*Step
+	routing := false
 	for _, st := range drl.Steps {
 		switch {
 		case st.typ == "T":
@@ -153,9 +719,33 @@ func (drl *DrlData) GenGcode(cam *DrlCAM) {
 			drl.genChangeTool(cam, st)
 		case st.typ == "D":
 			drl.genDrillHole(cam, st)
+		case st.typ == "S":
+			drl.genSlot(cam, []Point{{st.x, st.y}, {st.x2, st.y2}})
+		case st.typ == "P":
+			routing = true
+			routeStart = position
+			route = route[:0]
+		case st.typ == "U":
+			if routing {
+				drl.genSlot(cam, drl.getRoutePath(cam, routeStart, route))
+			}
+			routing = false
+		case routing:
+			route = append(route, st)
+		}
+
+		if st.typ == "G" || st.typ == "L" || st.typ == "C" || st.typ == "A" {
+			position = Point{st.x, st.y}
 		}
 	}
 
+	// A file that ends in the middle of a route still gets it cut
+	if routing {
+		drl.genSlot(cam, drl.getRoutePath(cam, routeStart, route))
+	}
+	drl.stopSpindle(cam)
+
+	return nil
 }
 
 func NewDrillBounds() *DrillBounds {
@@ -166,36 +756,61 @@ func NewDrillBounds() *DrillBounds {
 		yMax: -math.MaxFloat64,
 	}
 }
-func (drl *DrlData) GetBounds(bounds *DrillBounds) {
+
+// GetBounds grows bounds to take in every hole, slot and route in the file.  It's an error for any of them to use a tool
+// that the file never defined
+func (drl *DrlData) GetBounds(bounds *DrillBounds) error {
 	var tn int
 	for _, st := range drl.Steps {
-		switch {
-		case st.typ == "T":
+		if st.typ == "T" {
 			tn = st.tooln
+			continue
+		}
+
+		// Only the steps that cut need a tool
+		cuts := st.typ == "D" || st.typ == "S" || st.typ == "P" || st.typ == "L" || st.typ == "C" || st.typ == "A"
+		tool := drl.getTool(tn)
+		if tool == nil && cuts {
+			return fmt.Errorf("Drill step %s uses undefined tool T%d", st.typ, tn)
+		}
+
+		switch {
 		case st.typ == "D":
-			if tn >= len(drl.Tools) {
-				panic("")
-			}
-			fmt.Println("st.x = ", st.x, ", st.y = ", st.y)
-			xMin := st.x - drl.Tools[tn].size/2.0
-			xMax := st.x + drl.Tools[tn].size/2.0
-			yMin := st.y - drl.Tools[tn].size/2.0
-			yMax := st.y + drl.Tools[tn].size/2.0
-
-			if xMin < bounds.xMin {
-				bounds.xMin = xMin
-			}
-			if xMax > bounds.xMax {
-				bounds.xMax = xMax
-			}
-			if yMin < bounds.yMin {
-				bounds.yMin = yMin
-			}
-			if yMax > bounds.yMax {
-				bounds.yMax = yMax
-			}
+			bounds.update(st.x, st.y, tool.size)
+		case st.typ == "S":
+			bounds.update(st.x, st.y, tool.size)
+			bounds.update(st.x2, st.y2, tool.size)
+		case st.typ == "P" || st.typ == "L":
+			bounds.update(st.x, st.y, tool.size)
+		case st.typ == "C" || st.typ == "A":
+			// Allowing for the whole circle of an arc can only overestimate the bounds
+			radius := math.Hypot(st.x-st.cx, st.y-st.cy)
+			bounds.update(st.cx, st.cy, tool.size+(2.0*radius))
 		}
 	}
+
+	return nil
+}
+
+// update grows the bounds to take in a circle of the given diameter
+func (bounds *DrillBounds) update(x float64, y float64, size float64) {
+	xMin := x - size/2.0
+	xMax := x + size/2.0
+	yMin := y - size/2.0
+	yMax := y + size/2.0
+
+	if xMin < bounds.xMin {
+		bounds.xMin = xMin
+	}
+	if xMax > bounds.xMax {
+		bounds.xMax = xMax
+	}
+	if yMin < bounds.yMin {
+		bounds.yMin = yMin
+	}
+	if yMax > bounds.yMax {
+		bounds.yMax = yMax
+	}
 }
 
 var rmComment *regexp.Regexp
@@ -204,64 +819,23 @@ func init() {
 	rmComment = regexp.MustCompilePOSIX(`^(.*)[\t ]*[;](.*)`)
 }
 
-func (drl *DrlData) ParseDrlFile(rdr io.Reader) {
-	brdr := bufio.NewReader(rdr)
+// ParseDrlFile parses an Excellon drill file into drl, stopping at the first line it can't make sense of
+func (drl *DrlData) ParseDrlFile(rdr io.Reader) error {
+	parser := newDrlParser(drl)
+	scanner := bufio.NewScanner(rdr)
 
-	for {
-		line, pre, err := brdr.ReadLine()
-		if err == io.EOF {
-			return
-		}
-		if pre || err != nil {
-			panic("")
-		}
-		ln := string(line)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		ln := scanner.Text()
 		parsedLn := rmComment.FindAllStringSubmatch(ln, -1)
-		fmt.Println("ln = ", ln, ", parsedLn = ", parsedLn)
 		if len(parsedLn) > 0 {
 			ln = parsedLn[0][1]
+			parser.parseComment(parsedLn[0][2])
 		}
-		switch {
-		case ln == "":
-			continue
-		case ln == "%":
-			continue
-		case ln == "G05":
-			continue
-		case ln == "G90":
-			continue
-		case ln == "M48":
-		case ln == "M30":
-		case ln == "FMAT,2":
-		case ln == "INCH":
-			fallthrough
-		case ln == "METRIC":
-			drl.units = ln
-		case ln[0] == 'T':
-			tool, tooln, err := parseDefineTool(ln)
-			if err == nil {
-				for i := len(drl.Tools); i <= tooln; i++ {
-					drl.Tools = append(drl.Tools, nil)
-				}
-				drl.Tools[tooln] = tool
-			} else {
-				var step *Step
-				step, err = parseChangeTool(ln)
-				if err != nil {
-					panic("")
-				}
-				fmt.Println("tooln = ", tooln)
-				drl.Steps = append(drl.Steps, step)
-			}
-		case ln[0] == 'X':
-			var step *Step
-			step, err = parseDrillHole(ln)
-			if err != nil {
-				panic("")
-			}
-			drl.Steps = append(drl.Steps, step)
-		default:
-			panic("Unparsable")
+
+		if err := parser.parseLine(strings.TrimSpace(ln)); err != nil {
+			return fmt.Errorf("Error on line %d of drill file: %s", lineNumber, err.Error())
 		}
 	}
+
+	return scanner.Err()
 }
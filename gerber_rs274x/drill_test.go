@@ -0,0 +1,281 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const drillTestTolerance = 1e-9
+
+func parseTestDrl(t *testing.T, text string) *DrlData {
+	t.Helper()
+
+	drl := NewDrlData()
+	if err := drl.ParseDrlFile(strings.NewReader(text)); err != nil {
+		t.Fatalf("ParseDrlFile failed: %v", err)
+	}
+	return drl
+}
+
+// getSteps returns the steps of the given types, leaving out the ones a test doesn't care about
+func getSteps(drl *DrlData, types string) []*Step {
+	var steps []*Step
+	for _, step := range drl.Steps {
+		if strings.Contains(types, step.typ) {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+func checkPoint(t *testing.T, what string, x float64, y float64, wantX float64, wantY float64) {
+	t.Helper()
+
+	if math.Abs(x-wantX) > drillTestTolerance || math.Abs(y-wantY) > drillTestTolerance {
+		t.Errorf("%s is (%f, %f), want (%f, %f)", what, x, y, wantX, wantY)
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		leadingZeros bool
+		value        string
+		want         float64
+	}{
+		// Inch files default to 2.4 format
+		{false, "015", 0.0381},
+		{true, "015", 38.1},
+		{false, "-015", -0.0381},
+		{true, "-015", -38.1},
+		{false, "15000", 38.1},
+		{true, "000150", 0.381},
+		{false, "1.5", 38.1},
+		{true, "1.5", 38.1},
+	}
+
+	for _, test := range tests {
+		parser := newDrlParser(NewDrlData())
+		parser.leadingZeros = test.leadingZeros
+
+		got, err := parser.parseNumber(test.value)
+		if err != nil {
+			t.Errorf("parseNumber(%q) failed: %v", test.value, err)
+			continue
+		}
+		if math.Abs(got-test.want) > drillTestTolerance {
+			t.Errorf("parseNumber(%q) with leadingZeros %v = %f, want %f", test.value, test.leadingZeros, got, test.want)
+		}
+	}
+}
+
+func TestParseNumberErrors(t *testing.T) {
+	parser := newDrlParser(NewDrlData())
+	for _, value := range []string{"", "-", "1.2.3", "1e"} {
+		if _, err := parser.parseNumber(value); err == nil {
+			t.Errorf("parseNumber(%q) should fail", value)
+		}
+	}
+}
+
+func TestParseDrlFileZeroSuppression(t *testing.T) {
+	tests := []struct {
+		units string
+		want  float64
+	}{
+		{"INCH,TZ", 0.0381},
+		{"INCH,LZ", 38.1},
+		{"METRIC,TZ", 0.015},
+		{"METRIC,LZ", 15.0},
+	}
+
+	for _, test := range tests {
+		drl := parseTestDrl(t, "M48\n"+test.units+"\nT1C0.1\n%\nT1\nX015Y015\nM30\n")
+		hits := getSteps(drl, "D")
+		if len(hits) != 1 {
+			t.Errorf("%s: got %d hits, want 1", test.units, len(hits))
+			continue
+		}
+		checkPoint(t, test.units+" hit", hits[0].x, hits[0].y, test.want, test.want)
+	}
+}
+
+func TestParseDrlFileFormatComment(t *testing.T) {
+	// The coordinate format is only given in a comment, and overrides the default 3.3 format of a metric file
+	drl := parseTestDrl(t, `M48
+;FILE_FORMAT=4:2
+METRIC,TZ
+T1C0.8
+%
+T1
+X1000Y2000
+M30
+`)
+
+	hits := getSteps(drl, "D")
+	if len(hits) != 1 {
+		t.Fatalf("Got %d hits, want 1", len(hits))
+	}
+	checkPoint(t, "Hit", hits[0].x, hits[0].y, 10.0, 20.0)
+	if size := drl.Tools[1].size; math.Abs(size-0.8) > drillTestTolerance {
+		t.Errorf("Tool size is %f, want 0.8", size)
+	}
+}
+
+func TestParseDrlFileSlot(t *testing.T) {
+	drl := parseTestDrl(t, `M48
+METRIC
+T1C1.0
+%
+T1
+X1.0Y1.0G85X3.0Y1.5
+M30
+`)
+
+	slots := getSteps(drl, "S")
+	if len(slots) != 1 {
+		t.Fatalf("Got %d slots, want 1", len(slots))
+	}
+	checkPoint(t, "Slot start", slots[0].x, slots[0].y, 1.0, 1.0)
+	checkPoint(t, "Slot end", slots[0].x2, slots[0].y2, 3.0, 1.5)
+	if slots[0].tooln != 1 {
+		t.Errorf("Slot uses tool %d, want 1", slots[0].tooln)
+	}
+}
+
+func TestParseDrlFileRepeat(t *testing.T) {
+	drl := parseTestDrl(t, `M48
+METRIC
+T1C1.0
+%
+T1
+X1.0Y1.0
+R3X0.5Y-0.25
+M30
+`)
+
+	hits := getSteps(drl, "D")
+	want := [][2]float64{{1.0, 1.0}, {1.5, 0.75}, {2.0, 0.5}, {2.5, 0.25}}
+	if len(hits) != len(want) {
+		t.Fatalf("Got %d hits, want %d", len(hits), len(want))
+	}
+	for i, hit := range hits {
+		checkPoint(t, "Hit", hit.x, hit.y, want[i][0], want[i][1])
+	}
+}
+
+func TestParseDrlFileRoute(t *testing.T) {
+	drl := parseTestDrl(t, `M48
+METRIC
+T1C1.0
+%
+T1
+G00X0Y0
+M15
+G01X10.0Y0
+G03X10.0Y10.0I0J5.0
+G02X0Y10.0A10.0
+M16
+G05
+X20.0Y20.0
+M30
+`)
+
+	steps := getSteps(drl, "GPLACUD")
+	wantTypes := []string{"G", "P", "L", "A", "C", "U", "D"}
+	if len(steps) != len(wantTypes) {
+		t.Fatalf("Got %d steps, want %d", len(steps), len(wantTypes))
+	}
+	for i, step := range steps {
+		if step.typ != wantTypes[i] {
+			t.Errorf("Step %d is %s, want %s", i, step.typ, wantTypes[i])
+		}
+	}
+
+	checkPoint(t, "Line end", steps[2].x, steps[2].y, 10.0, 0.0)
+
+	// I and J give the centre relative to the start of the arc
+	checkPoint(t, "Counterclockwise arc end", steps[3].x, steps[3].y, 10.0, 10.0)
+	checkPoint(t, "Counterclockwise arc centre", steps[3].cx, steps[3].cy, 10.0, 5.0)
+
+	// The radius picks the shorter clockwise arc, which bulges downwards with its centre above the chord
+	checkPoint(t, "Clockwise arc end", steps[4].x, steps[4].y, 0.0, 10.0)
+	checkPoint(t, "Clockwise arc centre", steps[4].cx, steps[4].cy, 5.0, 10.0+(5.0*math.Sqrt(3.0)))
+
+	checkPoint(t, "Retract", steps[5].x, steps[5].y, 0.0, 10.0)
+
+	// G05 goes back to drilling
+	checkPoint(t, "Hit", steps[6].x, steps[6].y, 20.0, 20.0)
+}
+
+func TestParseDrlFileErrors(t *testing.T) {
+	const header = "M48\nMETRIC\nT1C1.0\n%\n"
+	tests := []string{
+		"garbage\n",
+		"M48\nMETRIC,XZ\n",
+		"M48\nFURLONGS\n",
+		"M48\nT1\n%\n",
+		"M48\nT1CX\n",
+		"M48\nT1C1.0Q5\n",
+		"M48\nTX\n",
+		header + "X1.0Y1.0\n",
+		header + "T2\n",
+		header + "T1\nX1.0Y1.0X2.0\n",
+		header + "T1\nX1.0G85X2.0G85X3.0\n",
+		header + "T1\nG01G02X1.0\n",
+		header + "T1\nG99X1.0\n",
+		header + "T1\nR2X1.0\n",
+		header + "T1\nX1.0\nR0X1.0\n",
+		header + "T1\nX1.0Y-\n",
+		header + "T1\nX1.0I1.0\n",
+		header + "T1\nG02X1.0Y1.0\n",
+		header + "T1\nG02X10.0Y0A1.0\n",
+		header + "T1\nX1.0Z2.0\n",
+		header + "M15\n",
+	}
+
+	for _, text := range tests {
+		if err := NewDrlData().ParseDrlFile(strings.NewReader(text)); err == nil {
+			t.Errorf("ParseDrlFile(%q) should fail", text)
+		}
+	}
+}
+
+func TestGetBounds(t *testing.T) {
+	drl := parseTestDrl(t, `M48
+METRIC
+T1C1.0
+T2C2.0
+%
+T1
+X1.0Y1.0
+T2
+X5.0Y2.0G85X7.0Y2.0
+M30
+`)
+
+	bounds := NewDrillBounds()
+	if err := drl.GetBounds(bounds); err != nil {
+		t.Fatalf("GetBounds failed: %v", err)
+	}
+	xMin, xMax, yMin, yMax := bounds.Get()
+	checkPoint(t, "Bottom left", xMin, yMin, 0.5, 0.5)
+	checkPoint(t, "Top right", xMax, yMax, 8.0, 3.0)
+}
+
+func TestGetBoundsUndefinedTool(t *testing.T) {
+	// Tools are numbered from one, so the first slot is always empty
+	drl := &DrlData{
+		Tools: []*Tool{nil, nil, {typ: "C", size: 1.0}},
+		Steps: []*Step{{typ: "T", tooln: 1}, {typ: "D", x: 1.0, y: 1.0}},
+	}
+
+	if err := drl.GetBounds(NewDrillBounds()); err == nil {
+		t.Errorf("GetBounds should fail for an undefined tool")
+	}
+
+	drl.Steps = []*Step{{typ: "D", x: 1.0, y: 1.0}}
+	if err := drl.GetBounds(NewDrillBounds()); err == nil {
+		t.Errorf("GetBounds should fail for a hit before any tool is selected")
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -13,6 +14,7 @@ type Layer struct {
 	fname  string
 	fext   string
 	mirror bool
+	back   bool // milled from the back of the board
 }
 
 var layers = []*Layer{
@@ -29,6 +31,7 @@ var layers = []*Layer{
 		fname:  "-B_Cu",
 		fext:   ".gbr",
 		mirror: true,
+		back:   true,
 	},
 	{
 		name:   "Edge Cuts",
@@ -51,10 +54,31 @@ func main() {
 
 	var inFiles []*os.File
 
-	if len(os.Args) != 2 {
+	// Clearing the copper left between the isolation cuts is optional, and needs a tool to do it with
+	clearTool := flag.Float64("clear", 0.0, "also clear the copper inside the board outline, with a tool this wide (mm)")
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) != 1 && len(args) != 2 {
 		panic("usage")
 	}
-	fname := os.Args[1]
+	fname := args[0]
+
+	// An optional tool library maps the drill sizes onto the bits the shop has
+	var library *gerber_rs274x.ToolLibrary
+	if len(args) == 2 {
+		libraryFile, err := os.Open(args[1])
+		if err != nil {
+			fmt.Printf("Error opening tool library %s: %s\n", args[1], err.Error())
+			os.Exit(2)
+		}
+		library, err = gerber_rs274x.ReadToolLibrary(libraryFile)
+		libraryFile.Close()
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(5)
+		}
+	}
 
 	bounds := gerber_rs274x.ImageBounds{}
 
@@ -73,20 +97,45 @@ func main() {
 	for i, ext := range layers {
 		switch {
 		case ext.typ == "COPPER":
-			AST, err = gerber_rs274x.ParseGerberFile(inFiles[i])
+			var parseErrors gerber_rs274x.ParseErrorList
+			AST, parseErrors, err = gerber_rs274x.ParseGerberFile(inFiles[i])
+			if err != nil {
+				panic("gerber parse fail")
+			}
+			for _, parseError := range parseErrors {
+				fmt.Printf("%s: %v\n", ext.name, parseError)
+			}
+			ASTs[i] = AST
+			gerber_rs274x.GenerateBounds(AST.([]gerber_rs274x.DataBlock), &bounds)
+		case ext.typ == "EDGE":
+			AST, _, err = gerber_rs274x.ParseGerberFile(inFiles[i])
 			if err != nil {
 				panic("gerber parse fail")
 			}
 			ASTs[i] = AST
+			// The outline is the edge of the board, so it puts the corner of the board at the origin
 			gerber_rs274x.GenerateBounds(AST.([]gerber_rs274x.DataBlock), &bounds)
 		case ext.typ == "DRILL":
 			drl := gerber_rs274x.NewDrlData()
-			drl.ParseDrlFile(inFiles[3])
+			err = drl.ParseDrlFile(inFiles[3])
+			if err != nil {
+				fmt.Printf("Error parsing drill file: %s\n", err.Error())
+				os.Exit(5)
+			}
+			if library != nil {
+				for _, mapping := range drl.MapTools(library) {
+					fmt.Println(mapping)
+				}
+			}
 
 			ASTs[i] = drl
 
 			dbounds := gerber_rs274x.NewDrillBounds()
-			drl.GetBounds(dbounds)
+			err = drl.GetBounds(dbounds)
+			if err != nil {
+				fmt.Printf("Error in drill file: %s\n", err.Error())
+				os.Exit(5)
+			}
 			bounds.UpdateBounds(dbounds.Get())
 		}
 	}
@@ -108,6 +157,23 @@ func main() {
 		return //
 	}
 
+	// The milling outputs share tsFunc's frame, so the copper, outline and holes line up.  The back is milled with the
+	// board flipped over, which mirrors it left to right within the same frame
+	tsbFunc := func(x float64, y float64) (x0 float64, y0 float64) {
+		return xMax - x, y - yMin
+	}
+
+	// Clearing copper needs the board outline as well as the copper layer
+	edgeLayer := -1
+	for i, ext := range layers {
+		if ext.typ == "EDGE" {
+			edgeLayer = i
+		}
+	}
+	if *clearTool > 0.0 && edgeLayer < 0 {
+		fmt.Println("No board outline layer, so the copper won't be cleared")
+	}
+
 	var outputFile *os.File
 	for i, ext := range layers {
 		switch {
@@ -127,6 +193,52 @@ func main() {
 				fmt.Printf("Error generating toolpath file: %s\n", err.Error())
 				os.Exit(5)
 			}
+			outputFile.Close()
+
+			// Boards can also be milled, so write isolation cuts for the same layer
+			outputFile, err = os.Create(fname + layers[i].fname + "-iso.gcode")
+			if err != nil {
+				panic("")
+			}
+			millCam := &gerber_rs274x.MillCAM{Wrt: outputFile, SafeZ: 1.0, CutZ: -0.05, PlungeF: 50, CutF: 150, ToolDiameter: 0.2, Passes: 2, Overlap: 0.25, TranslateScale: tsFunc}
+			if layers[i].back {
+				millCam.TranslateScale = tsbFunc
+			}
+			err = gerber_rs274x.GenerateIsolation(millCam, ASTs[i].([]gerber_rs274x.DataBlock))
+			if err != nil {
+				fmt.Printf("Error generating isolation file: %s\n", err.Error())
+				os.Exit(5)
+			}
+			outputFile.Close()
+
+			// The rest of the copper inside the board outline can be cleared with a bigger tool, in a file of its own
+			if *clearTool > 0.0 && edgeLayer >= 0 {
+				clearName := fname + layers[i].fname + "-clear.gcode"
+				outputFile, err = os.Create(clearName)
+				if err != nil {
+					panic("")
+				}
+				pocketCam := &gerber_rs274x.PocketCAM{Wrt: outputFile, SafeZ: 1.0, CutZ: -0.05, PlungeF: 50, CutF: 300, ToolDiameter: *clearTool, Overlap: 0.4, Pattern: gerber_rs274x.OFFSET_POCKET_PATTERN, TranslateScale: millCam.TranslateScale}
+				err = gerber_rs274x.GenerateClearing(pocketCam, ASTs[i].([]gerber_rs274x.DataBlock), ASTs[edgeLayer].([]gerber_rs274x.DataBlock))
+				outputFile.Close()
+				// Clearing is an extra, so the other outputs are still written without it
+				if err != nil {
+					fmt.Printf("Error generating clearing file, skipping it: %s\n", err.Error())
+					os.Remove(clearName)
+				}
+			}
+		case ext.typ == "EDGE":
+			outputFile, err = os.Create(fname + layers[i].fname + ".gcode")
+			if err != nil {
+				panic("")
+			}
+			outlineCam := &gerber_rs274x.OutlineCAM{Wrt: outputFile, SafeZ: 1.0, CutZ: -1.7, PassDepth: 0.5, PlungeF: 50, CutF: 150, ToolDiameter: 2.0, TabCount: 4, TabWidth: 3.0, TabHeight: 0.6, TranslateScale: tsFunc}
+			err = gerber_rs274x.GenerateOutline(outlineCam, ASTs[i].([]gerber_rs274x.DataBlock))
+			if err != nil {
+				fmt.Printf("Error generating outline file: %s\n", err.Error())
+				os.Exit(5)
+			}
+			outputFile.Close()
 		case ext.typ == "DRILL":
 
 			outputFile, err = os.Create(fname + ".gcode")
@@ -135,8 +247,17 @@ func main() {
 
 				os.Exit(2)
 			}
-			camo := &gerber_rs274x.DrlCAM{Wrt: outputFile, ChangeZ: 15.0, SafeZ: 1.0, DrillZ: -3.0, DrillF: 20, TranslateScale: tsFunc}
-			ASTs[i].(*gerber_rs274x.DrlData).GenGcode(camo)
+			// Tool changes go home to X0Y0, which tsFunc puts at the bottom left corner of the bounds
+			drl := ASTs[i].(*gerber_rs274x.DrlData)
+			before, after := drl.OptimizeDrillOrder(xMin, yMin)
+			fmt.Printf("Drill rapids: %f mm before ordering, %f mm after\n", before, after)
+
+			camo := &gerber_rs274x.DrlCAM{Wrt: outputFile, ChangeZ: 15.0, SafeZ: 1.0, DrillZ: -3.0, DrillF: 20, SlotOverlap: 0.5, TranslateScale: tsFunc}
+			err = drl.GenGcode(camo)
+			if err != nil {
+				fmt.Printf("Error generating drill file: %s\n", err.Error())
+				os.Exit(5)
+			}
 			outputFile.Close()
 		default:
 			fmt.Println("Unimpl ", ext.typ)
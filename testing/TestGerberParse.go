@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/clucia/go-gerber-rs274x/gerber_cairo"
 	"github.com/clucia/go-gerber-rs274x/gerber_rs274x"
 )
 
@@ -19,20 +20,30 @@ func main() {
 		os.Exit(2)
 	} else {
 
-		if parsedFile, err := gerber_rs274x.ParseGerberFile(inputFile); err != nil {
+		// Pass "-strict" after the filename to stop at the first spec violation
+		var parseOptions gerber_rs274x.ParseOptions
+		if len(os.Args) > 2 && os.Args[2] == "-strict" {
+			parseOptions.Mode = gerber_rs274x.STRICT_PARSE_MODE
+		}
+
+		if parsedFile, parseErrors, err := gerber_rs274x.ParseGerberFileWithOptions(inputFile, parseOptions); err != nil {
 			inputFile.Close()
 			fmt.Printf("Error parsing gerber file: %v\n", err)
 			os.Exit(3)
 		} else {
 			inputFile.Close()
 
+			for _, parseError := range parseErrors {
+				fmt.Println(parseError)
+			}
+
 			if parsedFile == nil {
 
 			}
 
 			outputFileName := filepath.Base(os.Args[1] + ".png")
 
-			if err := gerber_rs274x.GenerateSurface(outputFileName, parsedFile); err != nil {
+			if err := gerber_cairo.GenerateSurface(outputFileName, parsedFile); err != nil {
 				fmt.Printf("Error generating PNG file: %s\n", err.Error())
 				os.Exit(5)
 			}